@@ -0,0 +1,104 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"robpike.io/ivy/parse"
+	"robpike.io/ivy/scan"
+	"robpike.io/ivy/value"
+)
+
+// translateFiles prints the programs in the files named on the command line,
+// or standard input if there are none, rewritten between APL and ivy.
+// It reports whether they were translated without error.
+func translateFiles(context value.Context) bool {
+	names := flag.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	ok := true
+	for _, name := range names {
+		var fd io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ivy: %s\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			fd = f
+		}
+		if !translate(context, name, fd) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// translate prints the program read from fd, which may use APL glyphs or
+// ivy names for operators, using ivy names or, if -toapl is set, APL glyphs.
+// The program is parsed but not executed; each line is printed as ivy's
+// ProgString shows it, so spacing and parenthesization are canonical and
+// comments are dropped. It reports whether every line was translated.
+func translate(context value.Context, name string, fd io.Reader) bool {
+	scanner := scan.New(context, name, bufio.NewReader(fd))
+	parser := parse.NewParser(name, scanner, context)
+	ok := true
+	for {
+		text, more, lineOK := translateLine(parser)
+		if !more {
+			return ok
+		}
+		if !lineOK {
+			ok = false
+			continue
+		}
+		if *toAPL {
+			text = aplText(context, text)
+		}
+		fmt.Println(text)
+	}
+}
+
+// translateLine returns the next line of the program as ivy source text.
+// The boolean more is false at EOF; ok is false if the line has an error,
+// which is reported on standard error.
+func translateLine(parser *parse.Parser) (text string, more, ok bool) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		if err, isErr := err.(value.Error); isErr {
+			fmt.Fprintf(os.Stderr, "%s%s\n", parser.Loc(), err)
+			more, ok = true, false
+			return
+		}
+		panic(err)
+	}()
+	text, more = parser.Source()
+	return text, more, true
+}
+
+// aplText rewrites the ivy source text, which may span lines, using APL glyphs.
+// Special commands are left as they are.
+func aplText(context value.Context, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ")") {
+			continue
+		}
+		body := strings.TrimLeft(line, "\t")
+		lines[i] = line[:len(line)-len(body)] + scan.ToAPL(context, body)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/mobile"
+	"robpike.io/ivy/parse"
+	"robpike.io/ivy/scan"
+)
+
+// Each APL line translates to the ivy line, and back again.
+var aplTests = []struct {
+	apl, ivy string
+}{
+	{"x←2 3⍴⍳6", "x = 2 3 rho iota 6"},
+	{"+/x ⋄ +⌿x", "+/ x; +/% x"},
+	{"(⍳3)∘.×⍳3", "(iota 3) o.* iota 3"},
+	{"(2 2⍴⍳4)+.×2 2⍴⍳4", "(2 2 rho iota 4) +.* 2 2 rho iota 4"},
+	{"⌈/¯3 1 4", "max/ -3 1 4"},
+	{"×¯2 ⋄ 2×3", "sgn -2; 2 * 3"},
+	{"x[1; 2]←¯1÷100", "x[1; 2] = -1 / 100"},
+	{"1 2 3∊2 ⋄ ~1 0 ⋄ 1 2 3~2", "1 2 3 in 2; not 1 0; 1 2 3 without 2"},
+	{"op fac n = ×/⍳n", "op fac n = */ iota n"},
+	{"fac¨⍳4", "fac each iota 4"},
+	{"sqrt 2", "sqrt 2"},
+	{")origin 0", ")origin 0"},
+}
+
+// Rationals have no APL form, so they translate to APL as divisions.
+var rationalAPLTests = []struct {
+	ivy, apl string
+}{
+	{"x[1; 2] = -1/100", "x[1; 2]←(¯1÷100)"},
+	{"0 12 encode ceil m * 5000/127", "0 12⊤⌈m×(5000÷127)"},
+	{"1/2 -1/3", "(1÷2) (¯1÷3)"},
+}
+
+func TestAPL(t *testing.T) {
+	var conf config.Config
+	context := exec.NewContext(&conf)
+	for _, test := range aplTests {
+		scanner := scan.New(context, "<test>", strings.NewReader(test.apl+"\n"))
+		ivy, _ := parse.NewParser("<test>", scanner, context).Source()
+		if ivy != test.ivy {
+			t.Errorf("from APL %q: got %q; want %q", test.apl, ivy, test.ivy)
+		}
+		if apl := aplText(context, test.ivy); apl != test.apl {
+			t.Errorf("to APL %q: got %q; want %q", test.ivy, apl, test.apl)
+		}
+	}
+	for _, test := range rationalAPLTests {
+		if apl := aplText(context, test.ivy); apl != test.apl {
+			t.Errorf("to APL %q: got %q; want %q", test.ivy, apl, test.apl)
+		}
+	}
+}
+
+// translateProgram returns the program rewritten, as by -fromapl or,
+// if toAPL is set, -toapl.
+func translateProgram(text string, toAPL bool) string {
+	var conf config.Config
+	context := exec.NewContext(&conf)
+	scanner := scan.New(context, "<test>", strings.NewReader(text))
+	parser := parse.NewParser("<test>", scanner, context)
+	var b strings.Builder
+	for {
+		line, ok := parser.Source()
+		if !ok {
+			return b.String()
+		}
+		if toAPL {
+			line = aplText(context, line)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// A program that declares an op before defining it still runs after
+// translation to APL and back.
+func TestAPLProgram(t *testing.T) {
+	prog := "op foo x\nop bar x = foo x\nop foo x = x+1\nbar 3\n"
+	apl := translateProgram(prog, true)
+	ivy := translateProgram(apl, false)
+	mobile.Reset()
+	result, err := mobile.Eval(ivy)
+	if err != nil || strings.TrimSpace(result) != "4" {
+		t.Errorf("program %q translated to APL %q and back to %q: got %q, %v; want 4", prog, apl, ivy, result, err)
+	}
+}
@@ -42,8 +42,10 @@ type Config struct {
 	random      *rand.Rand
 	maxBits     uint          // Maximum length of an integer; 0 means no limit.
 	maxDigits   uint          // Above this size, ints print in floating format.
+	maxLoop     uint          // Maximum iterations of a loop; 0 means no limit.
 	floatPrec   uint          // Length of mantissa of a BigFloat.
 	cpuTime     time.Duration // Elapsed time of last interactive command.
+	libPath     []string      // Directories searched for files to read.
 	// Bases: 0 means C-like, base 10 with 07 for octal and 0xa for hex.
 	inputBase  int
 	outputBase int
@@ -58,6 +60,7 @@ func (c *Config) init() {
 		c.random = rand.New(c.source)
 		c.maxBits = 1e6
 		c.maxDigits = 1e4
+		c.maxLoop = 1e6
 		c.floatPrec = 256
 	}
 }
@@ -222,6 +225,18 @@ func (c *Config) SetMaxDigits(digits uint) {
 	c.maxDigits = digits
 }
 
+// MaxLoop returns the maximum number of iterations of a loop.
+func (c *Config) MaxLoop() uint {
+	c.init()
+	return c.maxLoop
+}
+
+// SetMaxLoop sets the maximum number of iterations of a loop.
+func (c *Config) SetMaxLoop(n uint) {
+	c.init()
+	c.maxLoop = n
+}
+
 // FloatPrec returns the floating-point precision in bits.
 // The exponent size is fixed by math/big.
 func (c *Config) FloatPrec() uint {
@@ -238,6 +253,17 @@ func (c *Config) SetFloatPrec(prec uint) {
 	c.floatPrec = prec
 }
 
+// LibPath returns the list of directories searched for a file read
+// by )get that is not found in the current directory.
+func (c *Config) LibPath() []string {
+	return c.libPath
+}
+
+// SetLibPath sets the list of directories searched for files read by )get.
+func (c *Config) SetLibPath(dirs []string) {
+	c.libPath = dirs
+}
+
 // CPUTime returns the duration of the last interactive operation.
 func (c *Config) CPUTime() time.Duration {
 	c.init()
@@ -0,0 +1,406 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/parse"
+	"robpike.io/ivy/value"
+)
+
+// The line editor understands these keys:
+//
+//	left, right, ^B, ^F  move the cursor
+//	home, end, ^A, ^E    move to the start or end of the line
+//	up, down, ^P, ^N     step through the history of previous lines
+//	backspace, ^H        delete the character before the cursor
+//	delete, ^D           delete the character under the cursor; ^D on an empty line is EOF
+//	^K, ^U               delete to the end or start of the line
+//	^W                   delete the word before the cursor
+//	^C                   discard the line
+//	tab                  complete the name before the cursor
+//
+// Names complete from the built-in ops, user-defined ops, global variables,
+// and, after a right paren at the start of the line, the special commands.
+
+const (
+	historyFile = ".ivy_history" // In the home directory.
+	maxHistory  = 1000           // Number of lines of history kept.
+)
+
+// editor is a line editor for interactive input from a terminal.
+// It implements io.ByteReader, delivering a line at a time to the scanner.
+type editor struct {
+	fd       int
+	in       *bufio.Reader
+	out      io.Writer
+	context  *exec.Context
+	history  []string
+	histFile string // The file holding the history; empty if none.
+	line     []byte // The edited text not yet delivered.
+	buf      []rune // The line being edited.
+	pos      int    // The position of the cursor in buf.
+	col      int    // The position of the cursor on the screen, relative to the start of buf.
+}
+
+// newEditor returns an editor reading from standard input, or nil
+// if standard input is not a terminal that supports editing.
+func newEditor(context value.Context) *editor {
+	fd := int(os.Stdin.Fd())
+	if !isTerminal(fd) || os.Getenv("TERM") == "dumb" {
+		return nil
+	}
+	e := &editor{
+		fd:      fd,
+		in:      bufio.NewReader(os.Stdin),
+		out:     os.Stdout,
+		context: context.(*exec.Context),
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		e.histFile = filepath.Join(home, historyFile)
+		e.loadHistory()
+	}
+	return e
+}
+
+// ReadByte returns the next byte of input, reading and editing
+// a new line when the previous one has been consumed.
+func (e *editor) ReadByte() (byte, error) {
+	if len(e.line) == 0 {
+		line, err := e.readLine()
+		if err != nil {
+			return 0, err
+		}
+		e.line = []byte(line + "\n")
+	}
+	c := e.line[0]
+	e.line = e.line[1:]
+	return c, nil
+}
+
+func ctrl(c rune) rune {
+	return c & 0x1f
+}
+
+// readLine reads and edits a line of input. The prompt has been printed.
+func (e *editor) readLine() (string, error) {
+	state, err := makeRaw(e.fd)
+	if err != nil {
+		// Fall back to reading the line as typed.
+		line, err := e.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimSuffix(line, "\n"), err
+	}
+	defer restoreTerminal(e.fd, state)
+	e.buf, e.pos, e.col = e.buf[:0], 0, 0
+	hist := len(e.history) // The history line being edited; len(e.history) is the new line.
+	current := ""          // The new line, saved while stepping through the history.
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		if r == 0x1b {
+			r = e.escape()
+		}
+		switch r {
+		case '\r', '\n':
+			e.move(len(e.buf))
+			e.write("\r\n")
+			line := string(e.buf)
+			e.addHistory(line)
+			return line, nil
+		case ctrl('A'), keyHome:
+			e.move(0)
+		case ctrl('E'), keyEnd:
+			e.move(len(e.buf))
+		case ctrl('B'), keyLeft:
+			e.move(e.pos - 1)
+		case ctrl('F'), keyRight:
+			e.move(e.pos + 1)
+		case ctrl('C'):
+			e.move(len(e.buf))
+			e.write("^C\r\n" + e.context.Config().Prompt())
+			e.buf, e.pos, e.col = e.buf[:0], 0, 0
+			hist = len(e.history)
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				e.write("\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case keyDelete:
+			e.delete(e.pos, e.pos+1)
+		case ctrl('H'), 0x7f:
+			e.delete(e.pos-1, e.pos)
+		case ctrl('K'):
+			e.delete(e.pos, len(e.buf))
+		case ctrl('U'):
+			e.delete(0, e.pos)
+		case ctrl('W'):
+			start := e.pos
+			for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+				start--
+			}
+			e.delete(start, e.pos)
+		case ctrl('P'), keyUp, ctrl('N'), keyDown:
+			next := hist - 1
+			if r == ctrl('N') || r == keyDown {
+				next = hist + 1
+			}
+			if next < 0 || next > len(e.history) {
+				e.write("\a")
+				break
+			}
+			if hist == len(e.history) {
+				current = string(e.buf)
+			}
+			hist = next
+			text := current
+			if hist < len(e.history) {
+				text = e.history[hist]
+			}
+			e.set(text)
+		case '\t':
+			e.complete()
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(string(r))
+			}
+		}
+	}
+}
+
+// Keys delivered as escape sequences are given values outside Unicode.
+const (
+	keyUnknown = unicode.MaxRune + 1 + iota
+	keyUp
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+)
+
+// escape reads the rest of an escape sequence, whose ESC has been read,
+// and returns the key it represents.
+func (e *editor) escape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != '[' && r != 'O' {
+		return keyUnknown
+	}
+	// Parameters are digits and semicolons; the sequence ends with a letter or ~.
+	param := ""
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return keyUnknown
+		}
+		if r != ';' && !('0' <= r && r <= '9') {
+			break
+		}
+		param += string(r)
+	}
+	switch r {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch param {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+	return keyUnknown
+}
+
+func (e *editor) write(s string) {
+	io.WriteString(e.out, s)
+}
+
+// moveCursor moves the cursor on the screen to position p of the line.
+func (e *editor) moveCursor(p int) {
+	switch {
+	case p < e.col:
+		fmt.Fprintf(e.out, "\x1b[%dD", e.col-p)
+	case p > e.col:
+		e.write(string(e.buf[e.col:p]))
+	}
+	e.col = p
+}
+
+// move moves the cursor to position p of the line, if it is valid.
+func (e *editor) move(p int) {
+	if p < 0 || p > len(e.buf) {
+		return
+	}
+	e.pos = p
+	e.moveCursor(p)
+}
+
+// redraw redraws the line from position p onwards, and puts back the cursor.
+func (e *editor) redraw(p int) {
+	e.moveCursor(p)
+	e.write(string(e.buf[p:]) + "\x1b[K")
+	e.col = len(e.buf)
+	e.moveCursor(e.pos)
+}
+
+// insert inserts s at the cursor.
+func (e *editor) insert(s string) {
+	r := []rune(s)
+	e.buf = append(e.buf[:e.pos], append(r, e.buf[e.pos:]...)...)
+	p := e.pos
+	e.pos += len(r)
+	e.redraw(p)
+}
+
+// delete deletes the text from start to end.
+func (e *editor) delete(start, end int) {
+	if start < 0 || end > len(e.buf) || start >= end {
+		return
+	}
+	e.buf = append(e.buf[:start], e.buf[end:]...)
+	e.pos = start
+	e.redraw(start)
+}
+
+// set replaces the line with text.
+func (e *editor) set(text string) {
+	e.buf = []rune(text)
+	e.pos = len(e.buf)
+	e.redraw(0)
+}
+
+// complete completes the name before the cursor, as far as it is unambiguous.
+// If it cannot be extended, the possible completions are listed.
+func (e *editor) complete() {
+	start := e.pos
+	for start > 0 && isNameRune(e.buf[start-1]) {
+		start--
+	}
+	word := string(e.buf[start:e.pos])
+	names := e.names()
+	if strings.TrimSpace(string(e.buf[:start])) == ")" {
+		names = parse.Specials
+	}
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		e.write("\a")
+	case 1:
+		e.insert(matches[0][len(word):] + " ")
+	default:
+		prefix := matches[0]
+		for _, m := range matches[1:] {
+			for !strings.HasPrefix(m, prefix) {
+				prefix = prefix[:len(prefix)-1]
+			}
+		}
+		if len(prefix) > len(word) {
+			e.insert(prefix[len(word):])
+			break
+		}
+		e.move(len(e.buf))
+		e.write("\r\n" + strings.Join(matches, " ") + "\r\n" + e.context.Config().Prompt())
+		e.col = 0
+		e.redraw(0)
+	}
+}
+
+// names returns, sorted, the names that may be completed: the built-in
+// and user-defined ops and the global variables.
+func (e *editor) names() []string {
+	seen := make(map[string]bool)
+	for name := range value.UnaryOps {
+		seen[name] = true
+	}
+	for name := range value.BinaryOps {
+		seen[name] = true
+	}
+	for _, def := range e.context.Defs {
+		seen[def.Name] = true
+	}
+	for name := range e.context.Stack[0] {
+		seen[name] = true
+	}
+	var names []string
+	for name := range seen {
+		if name != "_" && isNameRune([]rune(name)[0]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isNameRune reports whether r may appear in the name of an op or variable.
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// loadHistory reads the history file, keeping only the most recent lines.
+func (e *editor) loadHistory() {
+	data, err := ioutil.ReadFile(e.histFile)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	e.history = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		ioutil.WriteFile(e.histFile, []byte(strings.Join(e.history, "\n")+"\n"), 0600)
+	}
+}
+
+// addHistory adds the line to the history and appends it to the history file.
+func (e *editor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" || len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if e.histFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.histFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, line)
+	f.Close()
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/value"
+)
+
+// testEditor returns an editor reading input and writing to out,
+// with a user-defined op tally and a variable total.
+func testEditor(input string, out *bytes.Buffer) *editor {
+	var conf config.Config
+	context := exec.NewContext(&conf).(*exec.Context)
+	context.Define(&exec.Function{Name: "tally", Right: "x"})
+	context.Assign("total", value.Int(3))
+	return &editor{
+		in:      bufio.NewReader(strings.NewReader(input)),
+		out:     out,
+		context: context,
+	}
+}
+
+var escapeTests = []struct {
+	in  string // The sequence after the ESC.
+	key rune
+}{
+	{"[A", keyUp},
+	{"[B", keyDown},
+	{"[C", keyRight},
+	{"[D", keyLeft},
+	{"[H", keyHome},
+	{"[F", keyEnd},
+	{"OA", keyUp},
+	{"OH", keyHome},
+	{"[1~", keyHome},
+	{"[7~", keyHome},
+	{"[4~", keyEnd},
+	{"[8~", keyEnd},
+	{"[3~", keyDelete},
+	{"[1;5C", keyRight},
+	{"[5~", keyUnknown},
+	{"[Z", keyUnknown},
+	{"x", keyUnknown},
+	{"[", keyUnknown},
+	{"", keyUnknown},
+}
+
+func TestEditorEscape(t *testing.T) {
+	for _, test := range escapeTests {
+		var out bytes.Buffer
+		e := testEditor(test.in, &out)
+		if key := e.escape(); key != test.key {
+			t.Errorf("escape %q: got %#x; want %#x", test.in, key, test.key)
+		}
+	}
+}
+
+var completeTests = []struct {
+	line string // The line before completion, with the cursor at the end.
+	want string // The line after completion.
+	list string // The completions listed, if any.
+}{
+	{"tal", "tally ", ""},
+	{"1 + tot", "1 + total ", ""},
+	{"iot", "iota ", ""},
+	{"as", "asin", ""},
+	{"asin", "asin", "asin asinh"},
+	{"fl", "fl", "flip float floor"},
+	{"zzz", "zzz", ""},
+	{")cl", ")clear ", ""},
+	{")max", ")max", "maxbits maxdigits maxloop"},
+	{") o", ") o", "obase op ops origin"},
+}
+
+func TestEditorComplete(t *testing.T) {
+	for _, test := range completeTests {
+		var out bytes.Buffer
+		e := testEditor("", &out)
+		e.buf = []rune(test.line)
+		e.pos, e.col = len(e.buf), len(e.buf)
+		e.complete()
+		if got := string(e.buf); got != test.want {
+			t.Errorf("complete %q: got %q; want %q", test.line, got, test.want)
+		}
+		listed := strings.Contains(out.String(), "\r\n"+test.list+"\r\n")
+		if test.list != "" && !listed {
+			t.Errorf("complete %q: did not list %q in %q", test.line, test.list, out.String())
+		}
+		if test.want == test.line && test.list == "" && !strings.Contains(out.String(), "\a") {
+			t.Errorf("complete %q: no bell for failed completion", test.line)
+		}
+	}
+}
+
+func TestEditorNames(t *testing.T) {
+	var out bytes.Buffer
+	e := testEditor("", &out)
+	names := e.names()
+	has := make(map[string]bool)
+	for _, name := range names {
+		has[name] = true
+	}
+	for _, name := range []string{"tally", "total", "iota", "rho", "pi", "e"} {
+		if !has[name] {
+			t.Errorf("names does not include %q", name)
+		}
+	}
+	for _, name := range []string{"_", "+", "**", "=="} {
+		if has[name] {
+			t.Errorf("names includes %q", name)
+		}
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("names not sorted and unique: %q before %q", names[i-1], names[i])
+		}
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ivyhistory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var out bytes.Buffer
+	e := testEditor("", &out)
+	e.histFile = filepath.Join(dir, historyFile)
+	e.loadHistory() // No file yet.
+	if len(e.history) != 0 {
+		t.Fatalf("history from missing file: %q", e.history)
+	}
+	for _, line := range []string{"1+1", "1+1", "  ", "", "iota 3", "1+1"} {
+		e.addHistory(line)
+	}
+	want := []string{"1+1", "iota 3", "1+1"}
+	if !reflect.DeepEqual(e.history, want) {
+		t.Errorf("history: got %q; want %q", e.history, want)
+	}
+	e = testEditor("", &out)
+	e.histFile = filepath.Join(dir, historyFile)
+	e.loadHistory()
+	if !reflect.DeepEqual(e.history, want) {
+		t.Errorf("loaded history: got %q; want %q", e.history, want)
+	}
+
+	// A long history file is trimmed to the most recent lines.
+	var lines []string
+	for i := 0; i < maxHistory+5; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	if err := ioutil.WriteFile(e.histFile, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e.loadHistory()
+	if !reflect.DeepEqual(e.history, lines[5:]) {
+		t.Errorf("trimmed history has %d lines from %q; want %d from %q", len(e.history), e.history[0], maxHistory, lines[5])
+	}
+	data, err := ioutil.ReadFile(e.histFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); !reflect.DeepEqual(got, lines[5:]) {
+		t.Errorf("trimmed history file has %d lines; want %d", len(got), maxHistory)
+	}
+}
@@ -29,6 +29,12 @@ type Context struct {
 	UnaryFn map[string]*Function
 	//  BinaryFn maps the names of binary functions (ops) to their implemenations.
 	BinaryFn map[string]*Function
+	// AnonymousFn maps the text of anonymous binary ops, such as "{a+b}",
+	// to their implementations. The expression that uses an anonymous op
+	// holds its implementation and binds it here only while it is being
+	// evaluated; see BindAnonymous. They have no name, so they are not
+	// recorded in Defs.
+	AnonymousFn map[string]*Function
 	// Defs is a list of defined ops, in time order.  It is used when saving the
 	// Context to a file.
 	Defs []OpDef
@@ -40,10 +46,11 @@ type Context struct {
 // plus the execution configuration.
 func NewContext(conf *config.Config) value.Context {
 	c := &Context{
-		config:   conf,
-		Stack:    []Symtab{make(Symtab)},
-		UnaryFn:  make(map[string]*Function),
-		BinaryFn: make(map[string]*Function),
+		config:      conf,
+		Stack:       []Symtab{make(Symtab)},
+		UnaryFn:     make(map[string]*Function),
+		BinaryFn:    make(map[string]*Function),
+		AnonymousFn: make(map[string]*Function),
 	}
 	c.SetConstants()
 	return c
@@ -61,10 +68,12 @@ func (c *Context) SetConstants() {
 }
 
 // Lookup returns the value of a symbol.
+// A declared local variable that has not been assigned hides any
+// variable of the same name further down the stack, so Lookup
+// returns nil for it.
 func (c *Context) Lookup(name string) value.Value {
 	for i := len(c.Stack) - 1; i >= 0; i-- {
-		v := c.Stack[i][name]
-		if v != nil {
+		if v, ok := c.Stack[i][name]; ok {
 			return v
 		}
 	}
@@ -76,6 +85,16 @@ func (c *Context) assignLocal(name string, value value.Value) {
 	c.Stack[len(c.Stack)-1][name] = value
 }
 
+// declareLocals makes the names local to the current function,
+// even if they are also global variables. Until it is assigned,
+// a declared local has no value.
+func (c *Context) declareLocals(names []string) {
+	frame := c.Stack[len(c.Stack)-1]
+	for _, name := range names {
+		frame[name] = nil
+	}
+}
+
 // Assign assigns the variable the value. The variable must
 // be defined either in the current function or globally.
 // Inside a function, new variables become locals.
@@ -121,8 +140,21 @@ func (c *Context) Eval(exprs []value.Expr) []value.Value {
 	return values
 }
 
-// EvalUnary evaluates a unary operator, including reductions and scans.
+// EvalUnary evaluates a unary operator, including reductions, scans, and
+// each.
 func (c *Context) EvalUnary(op string, right value.Value) value.Value {
+	if strings.HasSuffix(op, " each") {
+		return value.Each(c, strings.TrimSuffix(op, " each"), right)
+	}
+	if len(op) > 2 && op[len(op)-1] == '%' {
+		// First-axis reduction or scan.
+		switch op[len(op)-2] {
+		case '/':
+			return value.ReduceAxis(c, op[:len(op)-2], 0, right)
+		case '\\':
+			return value.ScanAxis(c, op[:len(op)-2], 0, right)
+		}
+	}
 	if len(op) > 1 {
 		switch op[len(op)-1] {
 		case '/':
@@ -157,9 +189,12 @@ func (c *Context) UserDefined(op string, isBinary bool) bool {
 	return c.UnaryFn[op] != nil
 }
 
-// EvalBinary evaluates a binary operator, including products.
+// EvalBinary evaluates a binary operator, including products and each.
 func (c *Context) EvalBinary(left value.Value, op string, right value.Value) value.Value {
-	if strings.Contains(op, ".") {
+	if strings.HasSuffix(op, " each") {
+		return value.EachBinary(c, left, strings.TrimSuffix(op, " each"), right)
+	}
+	if isProduct(op) {
 		return value.Product(c, left, op, right)
 	}
 	fn := c.Binary(op)
@@ -169,11 +204,25 @@ func (c *Context) EvalBinary(left value.Value, op string, right value.Value) val
 	return fn.EvalBinary(c, left, right)
 }
 
+// isProduct reports whether op is an inner or outer product such as "+.*".
+// Periods inside an anonymous op, as in "{a*.5}", do not count.
+func isProduct(op string) bool {
+	if strings.HasPrefix(op, "{") {
+		n := value.BraceLen(op)
+		return n > 0 && n < len(op) && op[n] == '.'
+	}
+	return strings.Contains(op, ".")
+}
+
 func (c *Context) Binary(op string) value.BinaryOp {
 	user := c.BinaryFn[op]
 	if user != nil {
 		return user
 	}
+	anon := c.AnonymousFn[op]
+	if anon != nil {
+		return anon
+	}
 	builtin := value.BinaryOps[op]
 	if builtin != nil {
 		return builtin
@@ -211,6 +260,51 @@ func (c *Context) Define(fn *Function) {
 	c.Defs = append(c.Defs, OpDef{fn.Name, fn.IsBinary})
 }
 
+// Undefine removes the user-defined op and its entry in Defs.
+func (c *Context) Undefine(name string, isBinary bool) {
+	if isBinary {
+		delete(c.BinaryFn, name)
+	} else {
+		delete(c.UnaryFn, name)
+	}
+	for i, def := range c.Defs {
+		if def.Name == name && def.IsBinary == isBinary {
+			c.Defs = append(c.Defs[:i], c.Defs[i+1:]...)
+			break
+		}
+	}
+}
+
+// Clear deletes all variables and user-defined ops, returning the
+// context to its initial state. The configuration is unchanged.
+func (c *Context) Clear() {
+	c.Stack = []Symtab{make(Symtab)}
+	c.UnaryFn = make(map[string]*Function)
+	c.BinaryFn = make(map[string]*Function)
+	c.AnonymousFn = make(map[string]*Function)
+	c.Defs = nil
+	c.SetConstants()
+}
+
+// BindAnonymous binds the text of each of the anonymous ops to its
+// implementation, and returns a function that restores the previous bindings.
+func (c *Context) BindAnonymous(fns []*Function) (restore func()) {
+	saved := make([]*Function, len(fns))
+	for i, fn := range fns {
+		saved[i] = c.AnonymousFn[fn.Name]
+		c.AnonymousFn[fn.Name] = fn
+	}
+	return func() {
+		for i := len(fns) - 1; i >= 0; i-- {
+			if saved[i] == nil {
+				delete(c.AnonymousFn, fns[i].Name)
+			} else {
+				c.AnonymousFn[fns[i].Name] = saved[i]
+			}
+		}
+	}
+}
+
 // noVar guarantees that there is no global variable with that name,
 // preventing an op from being defined with the same name as a variable,
 // which could cause problems. A variable with value zero is considered to
@@ -218,7 +312,7 @@ func (c *Context) Define(fn *Function) {
 // variable is removed from the global symbol table.
 // noVar also prevents defining builtin variables as ops.
 func (c *Context) noVar(name string) {
-	if name == "_" || name == "pi" || name == "e" { // Cannot redefine these.
+	if name == "_" || name == "pi" || name == "e" || name == "each" { // Cannot redefine these.
 		value.Errorf(`cannot define op with name %q`, name)
 	}
 	sym := c.Stack[0][name]
@@ -235,7 +329,7 @@ func (c *Context) noVar(name string) {
 // noOp is the dual of noVar. It also checks for assignment to builtins.
 // It just errors out if there is a conflict.
 func (c *Context) noOp(name string) {
-	if name == "pi" || name == "e" { // Cannot redefine these.
+	if name == "pi" || name == "e" || name == "each" { // Cannot redefine these.
 		value.Errorf("cannot reassign %q", name)
 	}
 	if c.UnaryFn[name] == nil && c.BinaryFn[name] == nil {
@@ -6,6 +6,7 @@ package exec
 
 import (
 	"fmt"
+	"strings"
 
 	"robpike.io/ivy/value"
 )
@@ -16,15 +17,12 @@ type Function struct {
 	Name     string
 	Left     string
 	Right    string
+	Locals   []string // Declared local variables.
 	Body     []value.Expr
 }
 
 func (fn *Function) String() string {
-	left := ""
-	if fn.IsBinary {
-		left = fn.Left + " "
-	}
-	s := fmt.Sprintf("op %s%s %s =", left, fn.Name, fn.Right)
+	s := fn.Header() + " ="
 	if len(fn.Body) == 1 {
 		return s + " " + fn.Body[0].ProgString()
 	}
@@ -34,6 +32,20 @@ func (fn *Function) String() string {
 	return s
 }
 
+// Header returns the first part of the definition of the function,
+// up to but not including the equals sign, as in "op a gcd b".
+func (fn *Function) Header() string {
+	left := ""
+	if fn.IsBinary {
+		left = fn.Left + " "
+	}
+	s := fmt.Sprintf("op %s%s %s", left, fn.Name, fn.Right)
+	if len(fn.Locals) > 0 {
+		s += "; " + strings.Join(fn.Locals, " ")
+	}
+	return s
+}
+
 func (fn *Function) EvalUnary(context value.Context, right value.Value) value.Value {
 	if fn.Body == nil {
 		value.Errorf("unary %q undefined", fn.Name)
@@ -43,10 +55,8 @@ func (fn *Function) EvalUnary(context value.Context, right value.Value) value.Va
 	c.push()
 	defer c.pop()
 	c.assignLocal(fn.Right, right)
-	var v value.Value
-	for _, e := range fn.Body {
-		v = e.Eval(c)
-	}
+	c.declareLocals(fn.Locals)
+	v := EvalFunctionBody(c, fn.Name, fn.Body)
 	if v == nil {
 		value.Errorf("no value returned by %q", fn.Name)
 	}
@@ -63,12 +73,88 @@ func (fn *Function) EvalBinary(context value.Context, left, right value.Value) v
 	defer c.pop()
 	c.assignLocal(fn.Left, left)
 	c.assignLocal(fn.Right, right)
-	var v value.Value
-	for _, e := range fn.Body {
-		v = e.Eval(c)
-	}
+	c.declareLocals(fn.Locals)
+	v := EvalFunctionBody(c, fn.Name, fn.Body)
 	if v == nil {
 		value.Errorf("no value returned by %q", fn.Name)
 	}
 	return v
 }
+
+// EvalFunctionBody evaluates the list of expressions inside a function,
+// possibly with conditionals that generate an early return and loops.
+func EvalFunctionBody(context *Context, fnName string, body []value.Expr) value.Value {
+	v, _ := evalBody(context, fnName, body)
+	return v
+}
+
+// evalBody evaluates the statements of body. It returns the value of the
+// last statement evaluated and whether a conditional returned from the function.
+func evalBody(context *Context, fnName string, body []value.Expr) (value.Value, bool) {
+	var v value.Value
+	for _, e := range body {
+		if d, ok := e.(value.Decomposable); ok && d.Operator() == ":" {
+			left, right := d.Operands()
+			if isTrue(fnName, left.Eval(context)) {
+				return right.Eval(context), true
+			}
+			continue
+		}
+		if l, ok := e.(value.Loop); ok {
+			x, returned := evalLoop(context, fnName, l)
+			if returned {
+				return x, true
+			}
+			if x != nil {
+				v = x
+			}
+			continue
+		}
+		v = e.Eval(context)
+	}
+	return v, false
+}
+
+// evalLoop runs the loop until its condition is false or a conditional inside
+// its body returns from the function. To catch runaway loops, it errors out
+// if the loop runs more than the configured maximum number of iterations.
+func evalLoop(context *Context, fnName string, l value.Loop) (value.Value, bool) {
+	max := uint64(context.Config().MaxLoop())
+	var v value.Value
+	for i := uint64(0); isTrue(fnName, l.Condition().Eval(context)); i++ {
+		if max != 0 && i >= max {
+			value.Errorf("loop in %q did not terminate after %d iterations", fnName, max)
+		}
+		x, returned := evalBody(context, fnName, l.Body())
+		if returned {
+			return x, true
+		}
+		if x != nil {
+			v = x
+		}
+	}
+	return v, false
+}
+
+// isTrue reports whether v represents boolean truth. If v is not
+// a scalar, an error results.
+func isTrue(fnName string, v value.Value) bool {
+	switch i := v.Inner().(type) {
+	case value.Char:
+		return i != 0
+	case value.Int:
+		return i != 0
+	case value.BigInt:
+		return true // If it's a BigInt, it can't be 0 - that's an Int.
+	case value.BigFloat:
+		return i.Sign() != 0
+	case value.BigRat:
+		return true // If it's a BigRat, it can't be 0 - that's an Int.
+	case value.Vector:
+		if len(i) == 1 {
+			return isTrue(fnName, i[0])
+		}
+	}
+	value.Errorf("invalid expression %s for conditional inside %q", v, fnName)
+	return false
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"robpike.io/ivy/config"
@@ -26,14 +27,19 @@ var (
 	gformat   = flag.Bool("g", false, `shorthand for -format="%.12g"`)
 	maxbits   = flag.Uint("maxbits", 1e9, "maximum size of an integer, in bits; 0 means no limit")
 	maxdigits = flag.Uint("maxdigits", 1e4, "above this many `digits`, integers print as floating point; 0 disables")
+	maxloop   = flag.Uint("maxloop", 1e6, "maximum number of `iterations` of a loop; 0 means no limit")
 	origin    = flag.Int("origin", 1, "set index origin to `n` (must be 0 or 1)")
 	prompt    = flag.String("prompt", "", "command `prompt`")
+	initFile  = flag.String("init", "", "read `file` before standard input; default $HOME/.ivyrc, for a terminal only")
 	debugFlag = flag.String("debug", "", "comma-separated `names` of debug settings to enable")
+	fromAPL   = flag.Bool("fromapl", false, "print the input rewritten with ivy names for APL glyphs, without executing it")
+	toAPL     = flag.Bool("toapl", false, "print the input rewritten with APL glyphs for ivy names, without executing it")
 )
 
 var (
-	conf    config.Config
-	context value.Context
+	conf     config.Config
+	context  value.Context
+	initDone bool // Whether the startup file has been read.
 )
 
 func main() {
@@ -52,8 +58,10 @@ func main() {
 	conf.SetFormat(*format)
 	conf.SetMaxBits(*maxbits)
 	conf.SetMaxDigits(*maxdigits)
+	conf.SetMaxLoop(*maxloop)
 	conf.SetOrigin(*origin)
 	conf.SetPrompt(*prompt)
+	conf.SetLibPath(filepath.SplitList(os.Getenv("IVYPATH")))
 	if len(*debugFlag) > 0 {
 		for _, debug := range strings.Split(*debugFlag, ",") {
 			if !conf.SetDebug(debug, true) {
@@ -65,6 +73,17 @@ func main() {
 
 	context = exec.NewContext(&conf)
 
+	if *fromAPL || *toAPL {
+		if *fromAPL && *toAPL {
+			fmt.Fprintf(os.Stderr, "ivy: -fromapl and -toapl are mutually exclusive\n")
+			os.Exit(2)
+		}
+		if !translateFiles(context) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *execute {
 		runArgs(context)
 		return
@@ -73,21 +92,21 @@ func main() {
 	if flag.NArg() > 0 {
 		for i := 0; i < flag.NArg(); i++ {
 			name := flag.Arg(i)
-			var fd io.Reader
-			var err error
+			var in io.ByteReader
 			interactive := false
 			if name == "-" {
 				interactive = true
-				fd = os.Stdin
+				runInit(context)
+				in = stdin(context)
 			} else {
-				interactive = false
-				fd, err = os.Open(name)
-			}
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "ivy: %s\n", err)
-				os.Exit(1)
+				fd, err := os.Open(name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ivy: %s\n", err)
+					os.Exit(1)
+				}
+				in = bufio.NewReader(fd)
 			}
-			scanner := scan.New(context, name, bufio.NewReader(fd))
+			scanner := scan.New(context, name, in)
 			parser := parse.NewParser(name, scanner, context)
 			if !run.Run(parser, context, interactive) {
 				break
@@ -96,12 +115,62 @@ func main() {
 		return
 	}
 
-	scanner := scan.New(context, "<stdin>", bufio.NewReader(os.Stdin))
+	runInit(context)
+	scanner := scan.New(context, "<stdin>", stdin(context))
 	parser := parse.NewParser("<stdin>", scanner, context)
 	for !run.Run(parser, context, true) {
 	}
 }
 
+// runInit reads the startup file, named by the -init flag or by default
+// $HOME/.ivyrc, before standard input is first read. The default file
+// is read only when standard input is a terminal, since piped input is a
+// script, not a session, and a missing default file is not an error.
+func runInit(context value.Context) {
+	if initDone {
+		return
+	}
+	initDone = true
+	name := *initFile
+	if name == "" {
+		if !stdinIsTerminal() {
+			return
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		name = filepath.Join(home, ".ivyrc")
+		if _, err := os.Stat(name); err != nil {
+			return
+		}
+	}
+	fd, err := os.Open(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ivy: %s\n", err)
+		os.Exit(1)
+	}
+	defer fd.Close()
+	scanner := scan.New(context, name, bufio.NewReader(fd))
+	parser := parse.NewParser(name, scanner, context)
+	run.Run(parser, context, false)
+}
+
+// stdinIsTerminal reports whether standard input is a terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdin returns the reader for interactive input: a line editor if
+// standard input is a terminal, or standard input itself.
+func stdin(context value.Context) io.ByteReader {
+	if e := newEditor(context); e != nil {
+		return e
+	}
+	return bufio.NewReader(os.Stdin)
+}
+
 // runArgs executes the text of the command-line arguments as an ivy program.
 func runArgs(context value.Context) {
 	scanner := scan.New(context, "<args>", strings.NewReader(strings.Join(flag.Args(), " ")))
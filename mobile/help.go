@@ -31,7 +31,7 @@ Ivy is an interpreter for an APL-like language. It is a plaything and a work in
 progress.
 </p>
 <p>
-Unlike APL, the input is ASCII and the results are exact (but see the next paragraph).
+Unlike APL, the input is ASCII (but see below) and the results are exact (but see the next paragraph).
 It uses exact rational arithmetic so it can handle arbitrary precision. Values to be
 input may be integers (3, -1), rationals (1/3, -45/67) or floating point values (1e3,
 -1.5 (representing 1000 and -3/2)).
@@ -43,6 +43,15 @@ function, the result is stored in a high-precision floating-point number (defaul
 precision but are not exact.
 </p>
 <p>
+Complex numbers are written with a j separating the real and imaginary parts,
+as in 3j4 or 1/2j-3/4. Their parts may be any of the above, and arithmetic on
+them is exact when the parts are rational. The square root and logarithm of a
+negative number are complex: sqrt -4 is 0j2. The arithmetic operators, abs,
+sgn, sqrt, log, the exponential, and the trigonometric functions accept complex
+arguments; the comparisons other than == and != do not. A complex result with
+a zero imaginary part is real.
+</p>
+<p>
 Unlike in most other languages, operators always have the same precedence and
 expressions are evaluated in right-associative order. That is, unary operators
 apply to everything to the right, and binary operators apply to the operand
@@ -62,7 +71,18 @@ have most numerical operations supported eventually.
 </p>
 <p>
 Semicolons separate multiple statements on a line. Variables are alphanumeric and are
-assigned with the = operator. Assignment is an expression.
+assigned with the = operator. Assignment is an expression. Elements of a vector
+variable, or rows of a matrix, may be assigned by indexing the variable, as in
+v[3] = 7 or v[1 4] = 0 0. A scalar on the right is assigned to every selected
+element.
+</p>
+<p>
+Indexing with a single index, as in v[3] or m[2], selects along the first axis.
+To index a matrix along every axis, separate the indexes with semicolons, one
+per axis, as in m[2; 3]. An empty index selects the whole axis, so m[; 2] is the
+second column of m. Each index must be a scalar or a vector. The result has an
+axis for each vector index, as long as the index; a scalar index removes its
+axis. Such indexes may also be used for assignment, as in m[; 2] = 0.
 </p>
 <p>
 After each successful expression evaluation, the result is stored in the variable
@@ -77,6 +97,7 @@ Unary functions.
 </p>
 <pre>Name              APL   Ivy     Meaning
 Roll              ?B    ?       One integer selected randomly from the first B integers
+                                In ivy: ?0 is a random number uniformly distributed in [0, 1)
 Ceiling           ⌈B    ceil    Least integer greater than or equal to B
 Floor             ⌊B    floor   Greatest integer less than or equal to B
 Shape             ⍴B    rho     Number of components in each dimension of B
@@ -89,7 +110,7 @@ Identity          +B    +       No change to B
 Signum            ×B    sgn     ¯1 if B&lt;0; 0 if B=0; 1 if B&gt;0
 Reciprocal        ÷B    /       1 divided by B
 Ravel             ,B    ,       Reshapes B into a vector
-Matrix inverse    ⌹B            Inverse of matrix B
+Matrix inverse    ⌹B    inv     Inverse of matrix B
 Pi times          ○B            Multiply by π
 Logarithm         ⍟B    log     Natural logarithm of B
 Reversal          ⌽B    rot     Reverse elements of B along last axis
@@ -98,13 +119,34 @@ Grade up          ⍋B    up      Indices of B which will arrange B in ascending
 Grade down        ⍒B    down    Indices of B which will arrange B in descending order
 Execute           ⍎B    ivy     Execute an APL (ivy) expression
 Monadic format    ⍕B    text    A character representation of B
-Monadic transpose ⍉B            Reverse the axes of B
-Factorial         !B            Product of integers 1 to B
+Monadic transpose ⍉B    transp  Reverse the axes of B
+Unique            ∪B    unique  Distinct elements of vector B, in order of first occurrence
+Factorial         !B    !       Product of integers 1 to B
+                                In ivy: gamma(B+1) if B is not an integer
+Enclose           ⊂B    box     B as a scalar that may be an element of a vector
+Disclose          ⊃B    unbox   The contents of the boxed value B
 Bitwise not             ^       Bitwise complement of B (integer only)
 Square root       B⋆.5  sqrt    Square root of B.
 Sine                    sin     sin(A); APL uses binary ○ (see below)
 Cosine                  cos     cos(A); ditto
 Tangent                 tan     tan(A); ditto
+Hyperbolic sine         sinh    sinh(B); ditto
+Hyperbolic cosine       cosh    cosh(B); ditto
+Hyperbolic tangent      tanh    tanh(B); ditto
+Hyperbolic arcsine      asinh   asinh(B); ditto
+Hyperbolic arccosine    acosh   acosh(B); ditto
+Hyperbolic arctangent   atanh   atanh(B); ditto
+Normal random           randn   Random number, normally distributed with standard deviation B
+Exponential random      randexp Random number, exponentially distributed with mean B
+Primality               isprime 1 if B is prime; 0 otherwise (integer only)
+Factorization           factors Prime factors of B in increasing order (integer only)
+Gamma                   gamma   Gamma function of B; (B-1)! for positive integer B
+Determinant             det     Determinant of square matrix B
+Rank                    rank    Number of linearly independent rows of matrix B
+Trace                   trace   Sum of the diagonal elements of square matrix B
+Identity                identity B by B identity matrix
+LU decomposition        lu      Matrices L, U, P with P +.* B equal to L +.* U
+QR decomposition        qr      Matrices Q, R with B equal to Q +.* R
 </pre>
 <p>
 Binary functions.
@@ -117,16 +159,22 @@ Divide                A÷B   /       A divided by B (exact rational division)
                             div     A divided by B (Euclidean)
                             idiv    A divided by B (Go)
 Exponentiation        A⋆B   **      A raised to the B power
-Circle                A○B           Trigonometric functions of B selected by A
+Circle                A○B   circle  Trigonometric functions of B selected by A
                                     A=1: sin(B) A=2: cos(B) A=3: tan(B); ¯A for inverse
+                                    A=5: sinh(B) A=6: cosh(B) A=7: tanh(B); ¯A for inverse
+                                    A=0: sqrt(1-B²) A=4: sqrt(1+B²) A=8: sqrt(-1-B²)
+                                    A=9: real(B) A=10: abs(B) A=11: imag(B) A=12: phase(B)
                             sin     sin(B); ivy uses traditional name.
                             cos     cos(B); ivy uses traditional name.
                             tan     tan(B); ivy uses traditional name.
                             asin    arcsin(B); ivy uses traditional name.
                             acos    arccos(B); ivy uses traditional name.
                             atan    arctan(B); ivy uses traditional name.
-Deal                  A?B           A distinct integers selected randomly from the first B integers
+Deal                  A?B   ?       A distinct integers selected randomly from the first B integers
 Membership            A∈B   in      1 for elements of A present in B; 0 where not.
+Union                 A∪B   union   Distinct elements of A and B, in order of first occurrence
+Intersection          A∩B   intersect Distinct elements of A also in B, in order of first occurrence
+Without               A~B   without Elements of A not in B, in order (duplicates are kept)
 Maximum               A⌈B   max     The greater value of A or B
 Minimum               A⌊B   min     The smaller value of A or B
 Reshape               A⍴B   rho     Array of shape A with data B
@@ -144,13 +192,19 @@ Compression           A/B   sel     Select elements in B corresponding to ones i
                                     In ivy: abs(A) gives count, A &lt;= 0 inserts zero
 Index of              A⍳B   iota    The location (index) of B in A; 1+⌈/⍳⍴A if not found
                                     In ivy: origin-1 if not found (i.e. 0 if one-indexed)
-Matrix divide         A⌹B           Solution to system of linear equations Ax = B
+Matrix divide         A⌹B   mdiv    Solution to system of linear equations Bx = A
+                                    In ivy: exact for rational A and B; least squares
+                                    solution if B has more rows than columns
 Rotation              A⌽B   rot     The elements of B are rotated A positions left
 Rotation              A⊖B   flip    The elements of B are rotated A positions along the first axis
 Logarithm             A⍟B   log     Logarithm of B to base A
-Dyadic format         A⍕B           Format B into a character matrix according to A
-General transpose     A⍉B           The axes of B are ordered by A
-Combinations          A!B           Number of combinations of B taken A at a time
+Dyadic format         A⍕B   text    Format B into a character matrix according to A
+                                    A is width and decimal places, per column or for all;
+                                    a scalar A is decimal places, with width to fit
+General transpose     A⍉B   transp  The axes of B are ordered by A
+                                    Repeating an axis in A selects a diagonal of B
+Combinations          A!B   !       Number of combinations of B taken A at a time
+                                    In ivy: gamma(B+1)/(gamma(A+1)*gamma(B-A+1)) if not integers
 Less than             A&lt;B   &lt;       Comparison: 1 if true, 0 if false
 Less than or equal    A≤B   &lt;=      Comparison: 1 if true, 0 if false
 Equal                 A=B   ==      Comparison: 1 if true, 0 if false
@@ -162,6 +216,10 @@ And                   A∧B   and     Logic: 1 if A and B are 1; 0 otherwise
 Nor                   A⍱B   nor     Logic: 1 if both A and B are 0; otherwise 0
 Nand                  A⍲B   nand    Logic: 0 if both A and B are 1; otherwise 1
 Xor                         xor     Logic: 1 if A != B; otherwise 0
+Greatest common divisor     gcd     Largest integer dividing A and B (integer only)
+Least common multiple       lcm     Smallest non-negative integer multiple of A and B (integer only)
+Modular power               modpow  A[1] to the A[2] power modulo B (integer only)
+Modular inverse             modinv  Integer X with A*X = 1 modulo B (integer only)
 Bitwise and                 &amp;       Bitwise A and B (integer only)
 Bitwise or                  |       Bitwise A or B (integer only)
 Bitwise xor                 ^       Bitwise A exclusive or B (integer only)
@@ -173,13 +231,67 @@ Operators and axis indicator
 </p>
 <pre>Name                APL  Ivy  APL Example  Ivy Example  Meaning (of example)
 Reduce (last axis)  /    /    +/B          +/B          Sum across B
-Reduce (first axis) ⌿         +⌿B                       Sum down B
+Reduce (first axis) ⌿    /%   +⌿B          +/%B         Sum down B
 Scan (last axis)    \    \    +\B          +\B          Running sum across B
-Scan (first axis)   ⍀         +⍀B                       Running sum down B
+Scan (first axis)   ⍀    \%   +⍀B          +\%B         Running sum down B
 Inner product       .    .    A+.×B        A +.* B      Matrix product of A and B
 Outer product       ∘.   o.   A∘.×B        A o.* B      Outer product of A and B
                                                     (lower case o; may need preceding space)
+Each                ¨    each A f¨B        A f each B   Apply f to corresponding elements of A and B
 </pre>
+<h3 id="hdr-APL_glyphs">APL glyphs</h3>
+<p>
+So that APL text can be pasted into ivy, the scanner accepts the APL characters
+in the tables above as aliases for the corresponding ivy names. A glyph that is
+different in its unary and binary forms, such as × (sgn or *) or ⌈ (ceil or max),
+is binary if it follows an operand. The high minus ¯ is the sign of a negative
+number, ← is assignment, ⋄ separates statements, and ⍝ starts a comment. APL&#39;s
+A=B is still assignment in ivy; write A==B. Residue, A∣B, has no equivalent,
+since ivy&#39;s mod has its operands in the other order.
+</p>
+<p>
+The -fromapl and -toapl flags make ivy print its input, rewritten with ivy
+names or with APL glyphs for the operators, instead of executing it. The output
+is the program as ivy prints it, so comments are dropped and constants are shown
+in ivy&#39;s form. For -toapl, where 1/3 would be a replication, a rational is
+written as a parenthesized division, so x = 1/2 + iota 3 becomes x←(1÷2)+⍳3.
+</p>
+<p>
+A reduction or scan may be applied along any axis by giving the axis, counted
+from the origin, in brackets after the operator. For a matrix m, +/[1] m is the
+same as +/% m and +/[2] m is the same as +/m. The same syntax selects the axis
+for rot, flip, take, drop, and catenation: 2 rot[1] m rotates the rows of m,
+1 take[2] m is its first column, and m ,[1] x and m ,[2] x catenate x to m
+below and to the right. For catenation, an operand of rank one less than the
+other, or a scalar, is extended along the axis.
+</p>
+<p>
+The each operator applies a unary or binary operator, built-in or user-defined,
+separately to each element of its operands. For example, if fac is a
+user-defined factorial operator, fac each 1 2 3 is 1 2 6. A binary
+operator is applied to corresponding elements, with a scalar operand applied to
+every element of the other. Boxed elements are unboxed before the operator is
+applied, and results that are not scalars are boxed.
+</p>
+<h3 id="hdr-Linear_algebra">Linear algebra</h3>
+<p>
+The linear algebra operators inv, mdiv, det, rank, and lu compute exactly, using
+rational arithmetic, unless an element of the matrix is floating-point. The rank
+is always computed exactly. The qr decomposition is always floating-point, and
+requires a matrix with linearly independent columns. The lu and qr operators
+return a vector of boxed matrices: for lu, the unit lower triangular L, the upper
+triangular U, and the permutation matrix P; for qr, Q, with orthonormal columns,
+and the upper triangular R.
+</p>
+<h3 id="hdr-Boxed_values">Boxed values</h3>
+<p>
+The elements of a vector or matrix must be scalars, but box B encloses a vector
+or matrix B in a scalar that can be an element of another vector or matrix.
+For example, (box &#39;abc&#39;) (box &#39;de&#39;) is a vector of two strings, rho of which is 2.
+Boxes print in parentheses; a boxed matrix is shown as its shape, rho, and its
+data. Indexing a vector of boxes yields a box, and unbox returns its contents.
+Boxing a scalar has no effect, nor does unboxing a value that is not a box.
+</p>
 <p>
 Type-converting operations
 </p>
@@ -233,9 +345,8 @@ The syntax of a definition is the &#39;op&#39; keyword, the operator and formal
 arguments, an equals sign, and then the body. The names of the operator and its
 arguments must be identifiers.  For unary operators, write &#34;op name arg&#34;; for
 binary write &#34;op leftarg name rightarg&#34;. The final expression in the body is the
-return value. Operators may have recursive definitions, but since there are
-no conditional or looping constructs (yet), such operators are problematic
-when executed.
+return value. Operators may have recursive definitions; see the discussion of
+conditional execution below.
 </p>
 <p>
 The body may be a single line (possibly containing semicolons) on the same line
@@ -275,6 +386,56 @@ primes 50
 2 3 5 7 11 13 17 19 23 29 31 37 41 43 47
 </pre>
 <p>
+Conditional execution is done with the &#34;:&#34; binary conditional return operator,
+which is valid only within the body of a user-defined operator. The left operand
+must be a scalar; if it is non-zero, the right operand is returned as the value
+of the operator. Otherwise, execution continues with the next statement.
+A conditional may appear only at the top level of a statement; it cannot be
+nested inside another expression.
+</p>
+<p>
+Example: factorial (unary):
+</p>
+<pre>op fac n = n &lt;= 1 : 1; n * fac n-1
+fac 10
+result: 3628800
+</pre>
+<p>
+Example: greatest common divisor (binary):
+</p>
+<pre>op a gcd b =
+	a == b: a
+	a &gt; b: b gcd a-b
+	a gcd b-a
+
+1562 gcd 88
+result: 22
+</pre>
+<p>
+Looping is done with a :while statement, also valid only within the body of a
+user-defined operator. The statement begins with :while and a condition, which
+like that of a conditional must be a scalar. The statements that follow, up to
+a matching :end, are executed repeatedly as long as the condition is non-zero.
+The statements may be separated by semicolons or newlines, so a loop may be
+written on one line or span several lines of a multiline definition. A conditional
+inside a loop returns from the operator. To catch runaway loops, the number of
+iterations is limited by the maxloop setting (see below).
+</p>
+<p>
+Example: smallest prime factor (unary):
+</p>
+<pre>op factor n =
+	d = 2
+	:while d*d &lt;= n
+		(n mod d) == 0: d
+		d = d + 1
+	:end
+	n
+
+factor 1001
+result: 7
+</pre>
+<p>
 To declare an operator but not define it, omit the equals sign and what follows.
 </p>
 <pre>op foo x
@@ -289,7 +450,59 @@ result: 1/3
 <p>
 Within a user-defined operator, identifiers are local to the invocation unless
 they are undefined in the operator but defined globally, in which case they refer to
-the global variable. A mechanism to declare locals may come later.
+the global variable. To guarantee that a variable is local even when a global of
+the same name exists, list it after a semicolon in the declaration, before the
+equals sign. Declared locals have no value until they are assigned.
+</p>
+<p>
+Example: declared local variable:
+</p>
+<pre>t = 100
+op f x; t = t = x*x; t + 1
+f 3
+result: 10
+t
+result: 100
+</pre>
+<p>
+An anonymous operator is a binary operator body written between braces, such
+as {a + 2*b}. Within the braces, a and b are the left and right operands. An
+anonymous operator can be applied directly or used wherever a binary operator
+name may appear in a reduction, scan, or inner or outer product. It has no
+name, so it does not appear among the defined operators and is not saved,
+except as part of the text of an operator that uses it.
+</p>
+<p>
+Example: anonymous operators:
+</p>
+<pre>{a + 2*b}/ 1 2 3
+result: 17
+3 {b + a*a} 4
+result: 13
+(iota 3) o.{a - b} iota 3
+result:
+ 0 -1 -2
+ 1  0 -1
+ 2  1  0
+</pre>
+<h3 id="hdr-Startup_file">Startup file</h3>
+<p>
+Before an interactive session, ivy reads and executes the file .ivyrc in the
+home directory, if it exists, or the file named by the -init flag. It might,
+for instance, set the format or )get a library of operators. When standard
+input is not a terminal, as when a script is piped to ivy, .ivyrc is not read,
+so its settings do not change the script&#39;s output. A file named by -init is
+always read, and it is an error if it does not exist.
+</p>
+<h3 id="hdr-Line_editing">Line editing</h3>
+<p>
+When standard input is a terminal, ivy edits each input line. The arrow keys,
+or ^B, ^F, ^A, and ^E, move the cursor; backspace and delete, ^K, ^U, and ^W
+delete text; and ^C discards the line. The up and down arrows, or ^P and ^N,
+step through previous lines, which are kept in the file .ivy_history in the
+home directory. Tab completes the name before the cursor, of an operator or
+global variable, or of a special command after a right paren. On an empty
+line, ^D ends the session.
 </p>
 <h3 id="hdr-Special_commands">Special commands</h3>
 <p>
@@ -308,11 +521,19 @@ base 10 and must be non-negative on input.
 	any identifier formed from valid numerals in the base system, such
 	as abe for base 16, is taken to be a number. TODO: To output
 	large integers and rationals, base must be one of 0 2 8 10 16.
+) clear
+	Delete all variables and user-defined operators, as if ivy had
+	just started. The settings of the other special commands are kept.
 ) cpu
 	Print the duration of the last interactive calculation.
 ) debug name 0|1
 	Toggle or set the named debugging flag. With no argument, lists
 	the settings.
+) erase name ...
+	Delete the named variables and user-defined operators. Both
+	the unary and binary forms of an operator are deleted. An operator
+	cannot be deleted while another one that is not also being deleted
+	uses it.
 ) format &#34;&#34;
 	Set the format for printing values. If empty, the output is printed
 	using the output base. If non-empty, the format determines the
@@ -321,6 +542,9 @@ base 10 and must be non-negative on input.
 ) get &#34;save.ivy&#34;
 	Read input from the named file; return to interactive execution
 	afterwards. If no file is specified, read from &#34;save.ivy&#34;.
+	A relative name not found in the current directory is looked
+	for in each directory listed in the IVYPATH environment variable,
+	which is separated like PATH, so libraries can be read by name.
 	(Unimplemented on mobile.)
 ) maxbits 1e6
 	To avoid consuming too much memory, if an integer result would
@@ -330,10 +554,16 @@ base 10 and must be non-negative on input.
 	To avoid overwhelming amounts of output, if an integer has more
 	than this many digits, print it using the defined floating-point
 	format. If maxdigits is 0, integers are always printed as integers.
+) maxloop 1e6
+	To avoid hanging on a runaway loop, if a :while loop inside an
+	operator runs for more than this many iterations, abort the
+	calculation. If maxloop is 0, there is no limit; the default is 1e6.
 ) op X
 	Show the definition of the user-defined operator X. Inside the
 	definition, numbers are always shown base 10, ignoring the ibase
 	and obase.
+) ops
+	List the user-defined operators, in the order they were defined.
 ) origin 1
 	Set the origin for indexing a vector or matrix.
 ) prec 256
@@ -347,7 +577,10 @@ base 10 and must be non-negative on input.
 	&#34;save.ivy&#34;.
 	(Unimplemented on mobile.)
 ) seed 0
-	Set the seed for the ? operator.
+	Set the seed for the ? operator, randn, and randexp.
+) vars
+	List the variables, with the type of each and, for a vector or
+	matrix, its shape.
 </pre>
 </body></html>
 `
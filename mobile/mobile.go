@@ -96,6 +96,7 @@ func Reset() {
 	conf.SetFormat("")
 	conf.SetMaxBits(1e9)
 	conf.SetMaxDigits(1e4)
+	conf.SetMaxLoop(1e6)
 	conf.SetOrigin(1)
 	conf.SetPrompt("")
 	conf.SetBase(0, 0)
@@ -6,6 +6,7 @@ package parse
 
 import (
 	"fmt"
+	"strings"
 
 	"robpike.io/ivy/exec"
 	"robpike.io/ivy/scan"
@@ -17,11 +18,21 @@ import (
 //	"op" name arg <eol>
 //	"op" name arg '=' statements <eol>
 //	"op" arg name arg '=' statements <eol>
+//	"op" name arg ';' locals '=' statements <eol>
+//	"op" arg name arg ';' locals '=' statements <eol>
+//
+// locals:
+//	identifier...
 //
 // statements:
 //	expressionList
 //	'\n' (expressionList '\n')+ '\n' # For multiline definition, ending with blank line.
 //
+// Inside the statements, a conditional 'expr : expr' returns the value
+// of the right-hand side from the op if the left-hand side is true,
+// and ':while expr' ... ':end' repeats the enclosed statements while
+// the expression is true.
+//
 func (p *Parser) functionDefn() {
 	p.need(scan.Op)
 	fn := new(exec.Function)
@@ -33,8 +44,15 @@ func (p *Parser) functionDefn() {
 	if p.peek().Type == scan.Identifier {
 		idents = append(idents, p.next().Text)
 	}
+	// A semicolon introduces the list of local variables.
+	if p.peek().Type == scan.Semicolon {
+		p.next()
+		for p.peek().Type == scan.Identifier {
+			fn.Locals = append(fn.Locals, p.next().Text)
+		}
+	}
 	tok := p.next()
-	// Install the function in the symbol table so recursive ops work. (As if.)
+	// Install the function in the symbol table so recursive ops work.
 	var installMap map[string]*exec.Function
 	if len(idents) == 3 {
 		if idents[1] == "o" { // Poor choice due to outer product syntax.
@@ -56,6 +74,20 @@ func (p *Parser) functionDefn() {
 	if fn.Name == fn.Left || fn.Name == fn.Right {
 		p.errorf("argument name %q is function name", fn.Name)
 	}
+	for i, name := range fn.Locals {
+		switch name {
+		case fn.Name:
+			p.errorf("local name %q is function name", name)
+		case fn.Left, fn.Right:
+			p.errorf("local name %q is argument name", name)
+		}
+		for _, prev := range fn.Locals[:i] {
+			if name == prev {
+				p.errorf("local name %q declared twice", name)
+			}
+		}
+		p.context.Declare(name)
+	}
 	// Define it, but prepare to undefine if there's trouble.
 	p.context.Define(fn)
 	defer p.context.ForgetAll()
@@ -71,6 +103,11 @@ func (p *Parser) functionDefn() {
 		}
 	}()
 
+	p.inFunction = true
+	defer func() {
+		p.inFunction = false
+	}()
+
 	switch tok.Type {
 	case scan.Assign:
 		// Either one line:
@@ -114,17 +151,58 @@ func (p *Parser) functionDefn() {
 	}
 	p.context.Define(fn)
 	succeeded = true
-	for _, ref := range references(p.context, fn.Body) {
-		// One day this will work, but until we have ifs and such, warn.
-		if ref.Name == fn.Name && ref.IsBinary == fn.IsBinary {
-			p.Printf("warning: definition of %s is recursive\n", fn.Name)
-		}
-	}
 	if p.context.Config().Debug("parse") {
 		p.Printf("op %s %s %s = %s\n", fn.Left, fn.Name, fn.Right, tree(fn.Body))
 	}
 }
 
+// anonymousOps parses and returns the anonymous ops, such as "{a+2*b}",
+// that appear in the operator, which may also be a reduction, scan, or
+// product such as "{a+2*b}/" or "o.{a-b}". An anonymous op is a binary
+// op whose arguments are called a and b. It has no name; its text
+// identifies it, so it is not listed with the other ops or saved.
+// The expression using the operator binds the ops while it is evaluated.
+func (p *Parser) anonymousOps(op string) []*exec.Function {
+	var fns []*exec.Function
+	for _, text := range anonymousTexts(op) {
+		fn := &exec.Function{
+			IsBinary: true,
+			Name:     text,
+			Left:     "a",
+			Right:    "b",
+		}
+		if !p.inFunction {
+			defer p.context.ForgetAll()
+		}
+		p.context.Declare(fn.Left)
+		p.context.Declare(fn.Right)
+		scanner := scan.New(p.context, p.fileName, strings.NewReader(text[1:len(text)-1]))
+		parser := NewParser(p.fileName, scanner, p.context)
+		parser.inFunction = true
+		if parser.readTokensToNewline() {
+			fn.Body, _ = parser.expressionList()
+		}
+		if len(fn.Body) == 0 {
+			p.errorf("missing body in anonymous op %s", text)
+		}
+		fns = append(fns, fn)
+	}
+	return fns
+}
+
+// anonymousTexts returns the text of the anonymous ops in the operator.
+func anonymousTexts(op string) []string {
+	var texts []string
+	for i := 0; i < len(op); i++ {
+		if op[i] == '{' {
+			n := value.BraceLen(op[i:])
+			texts = append(texts, op[i:i+n])
+			i += n - 1
+		}
+	}
+	return texts
+}
+
 // references returns a list, in appearance order, of the user-defined ops
 // referenced by this function body. Only the first appearance creates an
 // entry in the list.
@@ -139,16 +217,32 @@ func references(c *exec.Context, body []value.Expr) []exec.OpDef {
 func doReferences(c *exec.Context, refs *[]exec.OpDef, expr value.Expr) {
 	switch e := expr.(type) {
 	case *unary:
-		if c.UnaryFn[e.op] != nil {
-			addReference(refs, e.op, false)
+		addOpReferences(c, refs, e.op, false)
+		doAnonymousReferences(c, refs, e.anon)
+		if e.axis != nil {
+			doReferences(c, refs, e.axis)
 		}
 		doReferences(c, refs, e.right)
 	case *binary:
-		if c.BinaryFn[e.op] != nil {
-			addReference(refs, e.op, true)
+		addOpReferences(c, refs, e.op, true)
+		doAnonymousReferences(c, refs, e.anon)
+		if e.axis != nil {
+			doReferences(c, refs, e.axis)
 		}
 		doReferences(c, refs, e.left)
 		doReferences(c, refs, e.right)
+	case *index:
+		doReferences(c, refs, e.left)
+		for _, axis := range e.axes {
+			if axis != nil {
+				doReferences(c, refs, axis)
+			}
+		}
+	case *loop:
+		doReferences(c, refs, e.cond)
+		for _, stmt := range e.body {
+			doReferences(c, refs, stmt)
+		}
 	case variableExpr:
 	case sliceExpr:
 		for _, v := range e {
@@ -166,6 +260,42 @@ func doReferences(c *exec.Context, refs *[]exec.OpDef, expr value.Expr) {
 	}
 }
 
+// addOpReferences adds the user-defined ops named in the operator,
+// which may be a reduction, scan, product, or each of them.
+func addOpReferences(c *exec.Context, refs *[]exec.OpDef, op string, isBinary bool) {
+	if strings.Contains(op, "{") {
+		return // Anonymous ops are handled by doAnonymousReferences.
+	}
+	op = strings.TrimSuffix(op, " each")
+	if !isBinary {
+		for _, suffix := range []string{"/%", `\%`, "/", `\`} {
+			if name := strings.TrimSuffix(op, suffix); name != op && name != "" {
+				op, isBinary = name, true
+				break
+			}
+		}
+	}
+	names := []string{op}
+	if isBinary && strings.Contains(op, ".") {
+		names = strings.SplitN(op, ".", 2)
+	}
+	for _, name := range names {
+		if c.UserDefined(name, isBinary) {
+			addReference(refs, name, isBinary)
+		}
+	}
+}
+
+// doAnonymousReferences adds the references made by the bodies of
+// the anonymous ops used by an operator.
+func doAnonymousReferences(c *exec.Context, refs *[]exec.OpDef, anon []*exec.Function) {
+	for _, fn := range anon {
+		for _, expr := range fn.Body {
+			doReferences(c, refs, expr)
+		}
+	}
+}
+
 func addReference(refs *[]exec.OpDef, name string, isBinary bool) {
 	// If it's already there, ignore. This is n^2 but n is tiny.
 	for _, ref := range *refs {
@@ -13,11 +13,19 @@ const specialHelpMessage = `
 	any identifier formed from valid numerals in the base system, such
 	as abe for base 16, is taken to be a number. TODO: To output
 	large integers and rationals, base must be one of 0 2 8 10 16.
+) clear
+	Delete all variables and user-defined operators, as if ivy had
+	just started. The settings of the other special commands are kept.
 ) cpu
 	Print the duration of the last interactive calculation.
 ) debug name 0|1
 	Toggle or set the named debugging flag. With no argument, lists
 	the settings.
+) erase name ...
+	Delete the named variables and user-defined operators. Both
+	the unary and binary forms of an operator are deleted. An operator
+	cannot be deleted while another one that is not also being deleted
+	uses it.
 ) format ""
 	Set the format for printing values. If empty, the output is printed
 	using the output base. If non-empty, the format determines the
@@ -26,6 +34,9 @@ const specialHelpMessage = `
 ) get "save.ivy"
 	Read input from the named file; return to interactive execution
 	afterwards. If no file is specified, read from "save.ivy".
+	A relative name not found in the current directory is looked
+	for in each directory listed in the IVYPATH environment variable,
+	which is separated like PATH, so libraries can be read by name.
 	(Unimplemented on mobile.)
 ) maxbits 1e6
 	To avoid consuming too much memory, if an integer result would
@@ -35,10 +46,16 @@ const specialHelpMessage = `
 	To avoid overwhelming amounts of output, if an integer has more
 	than this many digits, print it using the defined floating-point
 	format. If maxdigits is 0, integers are always printed as integers.
+) maxloop 1e6
+	To avoid hanging on a runaway loop, if a :while loop inside an
+	operator runs for more than this many iterations, abort the
+	calculation. If maxloop is 0, there is no limit; the default is 1e6.
 ) op X
 	Show the definition of the user-defined operator X. Inside the
 	definition, numbers are always shown base 10, ignoring the ibase
 	and obase.
+) ops
+	List the user-defined operators, in the order they were defined.
 ) origin 1
 	Set the origin for indexing a vector or matrix.
 ) prec 256
@@ -52,5 +69,8 @@ const specialHelpMessage = `
 	"save.ivy".
 	(Unimplemented on mobile.)
 ) seed 0
-	Set the seed for the ? operator.
+	Set the seed for the ? operator, randn, and randexp.
+) vars
+	List the variables, with the type of each and, for a vector or
+	matrix, its shape.
 `
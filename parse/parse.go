@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"robpike.io/ivy/exec"
 	"robpike.io/ivy/scan"
@@ -36,13 +37,32 @@ func tree(e interface{}) string {
 	case variableExpr:
 		return fmt.Sprintf("<var %s>", e.name)
 	case *unary:
+		if e.axis != nil {
+			return fmt.Sprintf("(%s[%s] %s)", e.op, tree(e.axis), tree(e.right))
+		}
 		return fmt.Sprintf("(%s %s)", e.op, tree(e.right))
 	case *binary:
 		// Special case for [].
 		if e.op == "[]" {
 			return fmt.Sprintf("(%s[%s])", tree(e.left), tree(e.right))
 		}
+		if e.axis != nil {
+			return fmt.Sprintf("(%s %s[%s] %s)", tree(e.left), e.op, tree(e.axis), tree(e.right))
+		}
 		return fmt.Sprintf("(%s %s %s)", tree(e.left), e.op, tree(e.right))
+	case *index:
+		s := fmt.Sprintf("(%s[", tree(e.left))
+		for i, axis := range e.axes {
+			if i > 0 {
+				s += "; "
+			}
+			if axis != nil {
+				s += tree(axis)
+			}
+		}
+		return s + "])"
+	case *loop:
+		return fmt.Sprintf("(:while %s %s)", tree(e.cond), tree(e.body))
 	case []value.Expr:
 		if len(e) == 1 {
 			return tree(e[0])
@@ -175,26 +195,40 @@ func isCompound(x interface{}) bool {
 
 type unary struct {
 	op    string
+	anon  []*exec.Function // Anonymous ops in op, as in {a+b}/ v.
+	axis  value.Expr       // Axis, as in +/[1] m; nil if none.
 	right value.Expr
 }
 
 func (u *unary) ProgString() string {
+	if u.axis != nil {
+		return fmt.Sprintf("%s[%s] %s", u.op, u.axis.ProgString(), u.right.ProgString())
+	}
 	return fmt.Sprintf("%s %s", u.op, u.right.ProgString())
 }
 
 func (u *unary) Eval(context value.Context) value.Value {
+	if u.anon != nil {
+		defer context.(*exec.Context).BindAnonymous(u.anon)()
+	}
+	if u.axis != nil {
+		axis := u.axis.Eval(context).Inner()
+		return value.UnaryAxis(context, u.op, axis, u.right.Eval(context).Inner())
+	}
 	return context.EvalUnary(u.op, u.right.Eval(context).Inner())
 }
 
 type binary struct {
 	op    string
+	anon  []*exec.Function // Anonymous ops in op, as in u {a+b} v.
+	axis  value.Expr       // Axis, as in x ,[1] y; nil if none.
 	left  value.Expr
 	right value.Expr
 }
 
 func (b *binary) ProgString() string {
 	var left string
-	if isCompound(b.left) {
+	if isCompound(b.left) && b.op != "=" {
 		left = fmt.Sprintf("(%s)", b.left.ProgString())
 	} else {
 		left = b.left.ProgString()
@@ -203,22 +237,129 @@ func (b *binary) ProgString() string {
 	if b.op == "[]" {
 		return fmt.Sprintf("%s[%s]", left, b.right.ProgString())
 	}
+	if b.axis != nil {
+		return fmt.Sprintf("%s %s[%s] %s", left, b.op, b.axis.ProgString(), b.right.ProgString())
+	}
 	return fmt.Sprintf("%s %s %s", left, b.op, b.right.ProgString())
 }
 
+func (b *binary) Operator() string {
+	return b.op
+}
+
+func (b *binary) Operands() (left, right value.Expr) {
+	return b.left, b.right
+}
+
 func (b *binary) Eval(context value.Context) value.Value {
+	if b.op == ":" {
+		value.Errorf("conditional %s used outside op body", b.ProgString())
+	}
 	rhs := b.right.Eval(context).Inner()
 	if b.op == "=" {
 		// Special handling as we cannot evaluate the left.
-		// We know the left is a variableExpr.
-		lhs := b.left.(variableExpr)
-		context.Assign(lhs.name, rhs)
+		// We know the left is a variableExpr or an indexed variableExpr.
+		switch lhs := b.left.(type) {
+		case variableExpr:
+			context.Assign(lhs.name, rhs)
+		case *binary:
+			// Indexed assignment, as in x[i] = v.
+			variable := lhs.left.(variableExpr)
+			index := lhs.right.Eval(context).Inner()
+			context.Assign(variable.name, value.IndexAssign(context, variable.Eval(context), index, rhs))
+		case *index:
+			// Indexed assignment, as in x[i; j] = v.
+			variable := lhs.left.(variableExpr)
+			indexes := lhs.indexes(context)
+			context.Assign(variable.name, value.IndexAssignAxes(context, variable.Eval(context), indexes, rhs))
+		}
 		return Assignment{Value: rhs}
 	}
+	if b.anon != nil {
+		defer context.(*exec.Context).BindAnonymous(b.anon)()
+	}
+	if b.axis != nil {
+		axis := b.axis.Eval(context).Inner()
+		lhs := b.left.Eval(context).Inner()
+		return value.BinaryAxis(context, lhs, b.op, axis, rhs)
+	}
 	lhs := b.left.Eval(context)
 	return context.EvalBinary(lhs, b.op, rhs)
 }
 
+// index is an index expression with a separate index for each axis,
+// as in m[i; j]. A nil index selects all the elements along its axis.
+type index struct {
+	left value.Expr
+	axes []value.Expr
+}
+
+func (x *index) ProgString() string {
+	var b bytes.Buffer
+	if isCompound(x.left) {
+		fmt.Fprintf(&b, "(%s)", x.left.ProgString())
+	} else {
+		b.WriteString(x.left.ProgString())
+	}
+	b.WriteByte('[')
+	for i, axis := range x.axes {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		if axis != nil {
+			b.WriteString(axis.ProgString())
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (x *index) Eval(context value.Context) value.Value {
+	indexes := x.indexes(context)
+	return value.Index(context, x.left.Eval(context).Inner(), indexes)
+}
+
+// indexes evaluates the indexes, right to left.
+func (x *index) indexes(context value.Context) []value.Value {
+	indexes := make([]value.Value, len(x.axes))
+	for i := len(x.axes) - 1; i >= 0; i-- {
+		if x.axes[i] != nil {
+			indexes[i] = x.axes[i].Eval(context).Inner()
+		}
+	}
+	return indexes
+}
+
+// loop is a ':while' statement inside an op body. It is evaluated by
+// the op (see exec.EvalFunctionBody), not by its own Eval method.
+type loop struct {
+	cond value.Expr
+	body []value.Expr
+}
+
+func (l *loop) Condition() value.Expr {
+	return l.cond
+}
+
+func (l *loop) Body() []value.Expr {
+	return l.body
+}
+
+func (l *loop) ProgString() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, ":while %s", l.cond.ProgString())
+	for _, stmt := range l.body {
+		fmt.Fprintf(&b, "; %s", stmt.ProgString())
+	}
+	b.WriteString("; :end")
+	return b.String()
+}
+
+func (l *loop) Eval(context value.Context) value.Value {
+	value.Errorf(":while used outside op body")
+	return nil
+}
+
 // Assignment is an implementation of Value that is created as the result of an assignment.
 // It can be type-asserted to discover whether the returned value was created by assignment,
 // such as is done in the interpreter to avoid printing the results of assignment expressions.
@@ -232,7 +373,8 @@ type Parser struct {
 	tokens     []scan.Token
 	fileName   string
 	lineNum    int
-	errorCount int // Number of errors.
+	errorCount int  // Number of errors.
+	inFunction bool // Whether we are parsing the body of an op.
 	context    *exec.Context
 }
 
@@ -345,6 +487,49 @@ func (p *Parser) Line() ([]value.Expr, bool) {
 	return exprs, true
 }
 
+// Source reads a line of input, or a multiline op definition, and returns it
+// as ivy program text, in the form printed by ProgString, without evaluating it.
+// A special command is returned as its text and is not executed.
+// The boolean is false at EOF.
+func (p *Parser) Source() (string, bool) {
+	if !p.readTokensToNewline() {
+		return "", false
+	}
+	switch p.peek().Type {
+	case scan.EOF:
+		return "", true
+	case scan.RightParen:
+		words := make([]string, len(p.tokens)-1)
+		for i, tok := range p.tokens[1:] {
+			words[i] = tok.Text
+		}
+		p.tokens = p.tokens[:0]
+		return ")" + strings.Join(words, " "), true
+	case scan.Op:
+		p.functionDefn()
+		def := p.context.Defs[len(p.context.Defs)-1]
+		fn := p.context.UnaryFn[def.Name]
+		if def.IsBinary {
+			fn = p.context.BinaryFn[def.Name]
+		}
+		if len(fn.Body) == 0 {
+			// A declaration, as in "op f x"; "op f x =" would start a definition.
+			return fn.Header(), true
+		}
+		if len(fn.Body) > 1 {
+			// A multiline definition ends with a blank line.
+			return fn.String() + "\n", true
+		}
+		return fn.String(), true
+	}
+	exprs, _ := p.expressionList()
+	text := make([]string, len(exprs))
+	for i, expr := range exprs {
+		text[i] = expr.ProgString()
+	}
+	return strings.Join(text, "; "), true
+}
+
 // readTokensToNewline returns the next line of input.
 // The boolean is false at EOF.
 // We read all tokens before parsing for easy error recovery
@@ -386,10 +571,10 @@ func (p *Parser) expressionList() ([]value.Expr, bool) {
 }
 
 // statementList:
-//	expr
-//	expr ';' expr
+//	statement
+//	statement ';' statement
 func (p *Parser) statementList() ([]value.Expr, bool) {
-	expr := p.expr()
+	expr := p.statement()
 	var exprs []value.Expr
 	if expr != nil {
 		exprs = []value.Expr{expr}
@@ -404,9 +589,85 @@ func (p *Parser) statementList() ([]value.Expr, bool) {
 	return exprs, true
 }
 
+// statement:
+//	expr
+//	expr ':' expr # Conditional; only inside an op body.
+//	':while' expr statements ':end' # Loop; only inside an op body.
+func (p *Parser) statement() value.Expr {
+	switch p.keyword() {
+	case "while":
+		return p.loop()
+	case "end":
+		p.errorf(":end without :while")
+	}
+	expr := p.expr()
+	if expr != nil && p.peek().Type == scan.Colon {
+		tok := p.next()
+		if !p.inFunction {
+			p.errorf("conditional outside op body")
+		}
+		expr = &binary{
+			left:  expr,
+			op:    tok.Text,
+			right: p.expr(),
+		}
+	}
+	return expr
+}
+
+// keyword returns the name of the keyword, such as "while" in ":while",
+// that starts the next statement, or the empty string if there is none.
+func (p *Parser) keyword() string {
+	if len(p.tokens) < 2 || p.tokens[0].Type != scan.Colon || p.tokens[1].Type != scan.Identifier {
+		return ""
+	}
+	return p.tokens[1].Text
+}
+
+// loop:
+//	':while' expr statements ':end'
+// The statements are separated by semicolons or newlines, so a loop
+// may span several lines of a multiline op definition.
+func (p *Parser) loop() value.Expr {
+	p.next() // ':'
+	p.next() // "while"
+	if !p.inFunction {
+		p.errorf(":while outside op body")
+	}
+	l := &loop{
+		cond: p.expr(),
+	}
+	for {
+		switch p.peek().Type {
+		case scan.Semicolon:
+			p.next()
+			continue
+		case scan.EOF:
+			// The body continues on the next line.
+			if !p.readTokensToNewline() || p.peek().Type == scan.EOF {
+				p.errorf("unterminated :while")
+			}
+			continue
+		}
+		if p.keyword() == "end" {
+			p.next() // ':'
+			p.next() // "end"
+			return l
+		}
+		if stmt := p.statement(); stmt != nil {
+			l.body = append(l.body, stmt)
+		}
+		switch p.peek().Type {
+		case scan.Semicolon, scan.EOF:
+		default:
+			p.errorf("unexpected %s in :while", p.peek())
+		}
+	}
+}
+
 // expr
 //	operand
-//	operand binop expr
+//	binary
 func (p *Parser) expr() value.Expr {
 	tok := p.next()
 	if p.peek().Type == scan.Assign && tok.Type != scan.Identifier {
@@ -415,61 +676,62 @@ func (p *Parser) expr() value.Expr {
 	expr := p.operand(tok, true)
 	tok = p.peek()
 	switch tok.Type {
-	case scan.EOF, scan.RightParen, scan.RightBrack, scan.Semicolon:
+	case scan.EOF, scan.RightParen, scan.RightBrack, scan.Semicolon, scan.Colon:
 		return expr
 	case scan.Identifier:
 		if p.context.DefinedBinary(tok.Text) {
 			p.next()
-			return &binary{
-				left:  expr,
-				op:    tok.Text,
-				right: p.expr(),
-			}
+			return p.binary(expr, tok.Text)
 		}
 	case scan.Assign:
 		p.next()
-		variable, ok := expr.(variableExpr)
-		if !ok {
+		if !isAssignable(expr) {
 			p.errorf("cannot assign to %s", tree(expr))
 		}
 		return &binary{
-			left:  variable,
+			left:  expr,
 			op:    tok.Text,
 			right: p.expr(),
 		}
 	case scan.Operator:
 		p.next()
-		return &binary{
-			left:  expr,
-			op:    tok.Text,
-			right: p.expr(),
-		}
+		return p.binary(expr, tok.Text)
 	}
 	p.errorf("after expression: unexpected %s", p.peek())
 	return nil
 }
 
+// isAssignable reports whether expr may be the left side of an assignment:
+// a variable or a singly indexed variable, as in x[i] or x[i; j].
+func isAssignable(expr value.Expr) bool {
+	switch e := expr.(type) {
+	case variableExpr:
+		return true
+	case *binary:
+		_, ok := e.left.(variableExpr)
+		return ok && e.op == "[]"
+	case *index:
+		_, ok := e.left.(variableExpr)
+		return ok
+	}
+	return false
+}
+
 // operand
 //	number
 //	char constant
 //	string constant
 //	vector
 //	operand [ Expr ]...
-//	unop Expr
+//	unary
 func (p *Parser) operand(tok scan.Token, indexOK bool) value.Expr {
 	var expr value.Expr
 	switch tok.Type {
 	case scan.Operator:
-		expr = &unary{
-			op:    tok.Text,
-			right: p.expr(),
-		}
+		expr = p.unary(tok.Text)
 	case scan.Identifier:
 		if p.context.DefinedUnary(tok.Text) {
-			expr = &unary{
-				op:    tok.Text,
-				right: p.expr(),
-			}
+			expr = p.unary(tok.Text)
 			break
 		}
 		fallthrough
@@ -484,22 +746,119 @@ func (p *Parser) operand(tok scan.Token, indexOK bool) value.Expr {
 	return expr
 }
 
+// unary parses the application of the unary operator, which has
+// been consumed, to the expression that follows.
+//
+// unary
+//	unop expr
+//	unop '[' expr ']' expr
+//	unop 'each' expr
+func (p *Parser) unary(op string) value.Expr {
+	u := &unary{
+		op:   op,
+		anon: p.anonymousOps(op),
+	}
+	if p.peek().Type == scan.LeftBrack {
+		u.axis = p.axis()
+	} else {
+		u.op = p.each(op)
+	}
+	u.right = p.expr()
+	return u
+}
+
+// binary parses the application of the binary operator, which has
+// been consumed, to the left operand and the expression that follows.
+//
+// binary
+//	operand binop expr
+//	operand binop '[' expr ']' expr
+//	operand binop 'each' expr
+func (p *Parser) binary(left value.Expr, op string) value.Expr {
+	b := &binary{
+		op:   op,
+		anon: p.anonymousOps(op),
+		left: left,
+	}
+	if p.peek().Type == scan.LeftBrack {
+		b.axis = p.axis()
+	} else {
+		b.op = p.each(op)
+	}
+	b.right = p.expr()
+	return b
+}
+
+// axis parses an axis specification, as in +/[1] m.
+//
+// axis
+//	'[' expr ']'
+func (p *Parser) axis() value.Expr {
+	p.next()
+	axis := p.expr()
+	tok := p.next()
+	if tok.Type != scan.RightBrack {
+		p.errorf("expected right bracket, found %s", tok)
+	}
+	return axis
+}
+
+// each returns the operator, with " each" appended if it is
+// followed by the each adverb, which is then consumed.
+//
+// each
+//	op
+//	op 'each'
+func (p *Parser) each(op string) string {
+	if tok := p.peek(); tok.Type == scan.Identifier && tok.Text == "each" {
+		p.next()
+		return op + " each"
+	}
+	return op
+}
+
 // index
 //	expr
 //	expr [ expr ]
 //	expr [ expr ] [ expr ] ....
+//	expr [ expr ; expr ; ... ]
+// In a list of indexes separated by semicolons, any index may be
+// empty to select all the elements along its axis.
 func (p *Parser) index(expr value.Expr) value.Expr {
 	for p.peek().Type == scan.LeftBrack {
 		p.next()
-		index := p.expr()
+		var axes []value.Expr
+		for {
+			var axis value.Expr
+			switch p.peek().Type {
+			case scan.Semicolon, scan.RightBrack:
+			default:
+				axis = p.expr()
+			}
+			axes = append(axes, axis)
+			if p.peek().Type != scan.Semicolon {
+				break
+			}
+			p.next()
+		}
 		tok := p.next()
 		if tok.Type != scan.RightBrack {
 			p.errorf("expected right bracket, found %s", tok)
 		}
+		if len(axes) > 1 {
+			expr = &index{
+				left: expr,
+				axes: axes,
+			}
+			continue
+		}
+		if axes[0] == nil {
+			p.errorf("empty index")
+		}
 		expr = &binary{
 			op:    "[]",
 			left:  expr,
-			right: index,
+			right: axes[0],
 		}
 	}
 	return expr
@@ -588,7 +947,7 @@ func (p *Parser) numberOrVector(tok scan.Token) value.Expr {
 
 func isScalar(v value.Value) bool {
 	switch v := v.(type) {
-	case value.Int, value.Char, value.BigInt, value.BigRat, value.BigFloat:
+	case value.Int, value.Char, value.BigInt, value.BigRat, value.BigFloat, value.Complex, value.Box:
 		return true
 	case Assignment:
 		return isScalar(v.Value)
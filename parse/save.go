@@ -75,6 +75,7 @@ func save(c *exec.Context, file string) {
 	ibase, obase := conf.Base()
 	fmt.Fprintf(out, ")maxbits %d\n", conf.MaxBits())
 	fmt.Fprintf(out, ")maxdigits %d\n", conf.MaxDigits())
+	fmt.Fprintf(out, ")maxloop %d\n", conf.MaxLoop())
 	fmt.Fprintf(out, ")origin %d\n", conf.Origin())
 	fmt.Fprintf(out, ")prompt %q\n", conf.Prompt())
 	fmt.Fprintf(out, ")format %q\n", conf.Format())
@@ -101,6 +102,10 @@ func save(c *exec.Context, file string) {
 		}
 		printed[def] = true
 		fmt.Fprintln(out, fn) // TODO: Does this need conf?
+		if len(fn.Body) > 1 {
+			// A multiline definition ends with a blank line.
+			fmt.Fprintln(out)
+		}
 	}
 
 	// Global variables.
@@ -190,6 +195,14 @@ func put(conf *config.Config, out io.Writer, val value.Value) {
 		put(conf, out, val.Shape())
 		fmt.Fprint(out, " rho ")
 		put(conf, out, val.Data())
+	case value.Complex:
+		put(conf, out, val.Real())
+		fmt.Fprint(out, "j")
+		put(conf, out, val.Imag())
+	case value.Box:
+		fmt.Fprint(out, "(box ")
+		put(conf, out, val.Unbox())
+		fmt.Fprint(out, ")")
 	default:
 		value.Errorf("internal error: can't save type %T", val)
 	}
@@ -10,8 +10,10 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"robpike.io/ivy/config"
+	"robpike.io/ivy/exec"
 	"robpike.io/ivy/scan"
 	"robpike.io/ivy/value"
 )
@@ -79,6 +81,32 @@ func truth(x bool) int {
 	return 0
 }
 
+// Specials lists the names of the special commands, such as the
+// "get" of )get. It is used for command completion.
+var Specials = []string{
+	"base",
+	"clear",
+	"cpu",
+	"debug",
+	"erase",
+	"format",
+	"get",
+	"help",
+	"ibase",
+	"maxbits",
+	"maxdigits",
+	"maxloop",
+	"obase",
+	"op",
+	"ops",
+	"origin",
+	"prec",
+	"prompt",
+	"save",
+	"seed",
+	"vars",
+}
+
 func (p *Parser) special() {
 	p.need(scan.RightParen)
 	conf := p.context.Config()
@@ -112,6 +140,8 @@ Switch:
 		case "obase":
 			obase = base
 		}
+	case "clear":
+		p.context.Clear()
 	case "cpu":
 		p.Printf("%s\n", conf.PrintCPUTime())
 	case "debug":
@@ -138,6 +168,15 @@ Switch:
 		if !conf.SetDebug(name, number != 0) {
 			p.Println("no such debug flag:", name)
 		}
+	case "erase":
+		var names []string
+		for p.peek().Type != scan.EOF {
+			names = append(names, p.need(scan.Operator, scan.Identifier).Text)
+		}
+		if len(names) == 0 {
+			p.errorf("no names to erase")
+		}
+		p.erase(names)
 	case "format":
 		if p.peek().Type == scan.EOF {
 			p.Printf("%q\n", conf.Format())
@@ -164,6 +203,13 @@ Switch:
 		}
 		max := p.nextDecimalNumber()
 		conf.SetMaxDigits(uint(max))
+	case "maxloop":
+		if p.peek().Type == scan.EOF {
+			p.Printf("%d\n", conf.MaxLoop())
+			break Switch
+		}
+		max := p.nextDecimalNumber64()
+		conf.SetMaxLoop(uint(max))
 	case "op":
 		name := p.need(scan.Operator, scan.Identifier).Text
 		fn := p.context.UnaryFn[name]
@@ -180,6 +226,14 @@ Switch:
 		if !found {
 			p.errorf("%q not defined", name)
 		}
+	case "ops":
+		for _, def := range p.context.Defs {
+			fn := p.context.UnaryFn[def.Name]
+			if def.IsBinary {
+				fn = p.context.BinaryFn[def.Name]
+			}
+			p.Println(fn.Header())
+		}
 	case "origin":
 		if p.peek().Type == scan.EOF {
 			p.Println(conf.Origin())
@@ -221,6 +275,14 @@ Switch:
 			break Switch
 		}
 		conf.SetRandomSeed(p.nextDecimalNumber64())
+	case "vars":
+		for _, sym := range sortSyms(p.context.Stack[0]) {
+			// pi and e are constants, and _ is just the last result.
+			if sym.name == "pi" || sym.name == "e" || sym.name == "_" {
+				continue
+			}
+			p.Printf("%s\t%s\n", sym.name, describe(conf, sym.val))
+		}
 	default:
 		p.errorf(")%s: not recognized", text)
 	}
@@ -232,6 +294,80 @@ Switch:
 	p.need(scan.EOF)
 }
 
+// describe returns the type of the value and, if it is not a scalar,
+// its shape, separated by a tab.
+func describe(conf *config.Config, val value.Value) string {
+	switch v := val.(type) {
+	case value.Int, value.BigInt:
+		return "int"
+	case value.BigRat:
+		return "rational"
+	case value.BigFloat:
+		return "float"
+	case value.Complex:
+		return "complex"
+	case value.Char:
+		return "char"
+	case value.Box:
+		return "box"
+	case value.Vector:
+		return fmt.Sprintf("vector\t%d", len(v))
+	case value.Matrix:
+		return "matrix\t" + v.Shape().Sprint(conf)
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// erase deletes the named variables and ops. An op is not deleted
+// while an op that is not also being deleted refers to it.
+func (p *Parser) erase(names []string) {
+	c := p.context
+	var ops []exec.OpDef
+	for _, name := range names {
+		if name == "pi" || name == "e" {
+			p.errorf("cannot erase %q", name)
+		}
+		found := false
+		for _, isBinary := range []bool{false, true} {
+			if c.UserDefined(name, isBinary) {
+				ops = append(ops, exec.OpDef{Name: name, IsBinary: isBinary})
+				found = true
+			}
+		}
+		if _, ok := c.Stack[0][name]; !ok && !found {
+			p.errorf("%q not defined", name)
+		}
+	}
+	erasing := func(def exec.OpDef) bool {
+		for _, op := range ops {
+			if op == def {
+				return true
+			}
+		}
+		return false
+	}
+	for _, def := range c.Defs {
+		if erasing(def) {
+			continue
+		}
+		fn := c.UnaryFn[def.Name]
+		if def.IsBinary {
+			fn = c.BinaryFn[def.Name]
+		}
+		for _, ref := range references(c, fn.Body) {
+			if erasing(ref) {
+				p.errorf("cannot erase %s; it is used by %s", ref.Name, def.Name)
+			}
+		}
+	}
+	for _, name := range names {
+		delete(c.Stack[0], name)
+	}
+	for _, op := range ops {
+		c.Undefine(op.Name, op.IsBinary)
+	}
+}
+
 // getString returns the value of the string that must be next in the input.
 func (p *Parser) getString() string {
 	return value.ParseString(p.need(scan.String).Text)
@@ -239,6 +375,25 @@ func (p *Parser) getString() string {
 
 var runDepth = 0
 
+// findFile returns the path of the named file to read. A relative name
+// that is not found in the current directory is looked for in each
+// directory of the library path in turn.
+func findFile(conf *config.Config, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	for _, dir := range conf.LibPath() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
 // runFromFile executes the contents of the named file.
 func (p *Parser) runFromFile(context value.Context, name string) {
 	runDepth++
@@ -257,10 +412,11 @@ func (p *Parser) runFromFile(context value.Context, name string) {
 		}
 		panic(err)
 	}()
-	fd, err := os.Open(name)
+	fd, err := os.Open(findFile(context.Config(), name))
 	if err != nil {
 		p.errorf("%s", err)
 	}
+	defer fd.Close()
 	scanner := scan.New(context, name, bufio.NewReader(fd))
 	parser := NewParser(name, scanner, p.context)
 	out := p.context.Config().Output()
@@ -0,0 +1,45 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parse
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"robpike.io/ivy/config"
+)
+
+func TestFindFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ivypath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"lib.ivy", "special.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x = 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var conf config.Config
+	missing := filepath.Join(dir, "missing")
+	conf.SetLibPath(filepath.SplitList(missing + string(filepath.ListSeparator) + dir))
+	abs := filepath.Join(dir, "abs.ivy")
+	tests := []struct {
+		name, want string
+	}{
+		{"lib.ivy", filepath.Join(dir, "lib.ivy")},     // Found in the library path.
+		{"special.go", "special.go"},                   // The current directory comes first.
+		{"nothere.ivy", "nothere.ivy"},                 // Not found; unchanged.
+		{abs, abs},                                     // Absolute; unchanged.
+		{"../parse/special.go", "../parse/special.go"}, // Relative, in the current directory.
+	}
+	for _, test := range tests {
+		if got := findFile(&conf, test.name); got != test.want {
+			t.Errorf("findFile(%q) = %q; want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"robpike.io/ivy/exec"
+	"robpike.io/ivy/value"
+)
+
+// So APL text can be pasted into ivy, the scanner accepts APL's glyphs.
+// As each is scanned, it is rewritten in the input as its ivy spelling,
+// so the tokens, and everything downstream of them, see only ivy.
+
+// glyph holds the ivy spellings of an APL function glyph applied to one
+// and to two operands. An empty spelling means ivy has no equivalent.
+type glyph struct {
+	unary, binary string
+}
+
+var glyphs = map[rune]glyph{
+	'⌈': {"ceil", "max"},
+	'⌊': {"floor", "min"},
+	'⍴': {"rho", "rho"},
+	'~': {"not", "without"},
+	'∼': {"not", "without"},
+	'∣': {"abs", ""}, // Residue has its operands reversed from mod.
+	'⍳': {"iota", "iota"},
+	'⋆': {"**", "**"},
+	'−': {"-", "-"},
+	'×': {"sgn", "*"},
+	'÷': {"/", "/"},
+	'⌹': {"inv", "mdiv"},
+	'○': {"pi *", "circle"},
+	'⍟': {"log", "log"},
+	'⌽': {"rot", "rot"},
+	'⊖': {"flip", "flip"},
+	'⍋': {"up", ""},
+	'⍒': {"down", ""},
+	'⍎': {"ivy", ""},
+	'⍕': {"text", "text"},
+	'⍉': {"transp", "transp"},
+	'∪': {"unique", "union"},
+	'∩': {"", "intersect"},
+	'⊂': {"box", ""},
+	'⊃': {"unbox", ""},
+	'∊': {"", "in"},
+	'∈': {"", "in"},
+	'↑': {"", "take"},
+	'↓': {"", "drop"},
+	'⊥': {"", "decode"},
+	'⊤': {"", "encode"},
+	'≤': {"", "<="},
+	'≥': {"", ">="},
+	'≠': {"", "!="},
+	'∨': {"", "or"},
+	'∧': {"", "and"},
+	'⍱': {"", "nor"},
+	'⍲': {"", "nand"},
+}
+
+// isGlyph reports whether r is an APL character the scanner translates.
+func isGlyph(r rune) bool {
+	switch r {
+	case '¯', '←', '⋄', '⍝', '∘', '¨', '⌿', '⍀':
+		return true
+	}
+	_, ok := glyphs[r]
+	return ok
+}
+
+// lexGlyph rewrites the APL glyph just scanned as its ivy spelling,
+// to be scanned again. Spaces around the spelling keep it separate
+// from its neighbors, except before a reduction, scan, or product,
+// which must follow the operator immediately.
+func lexGlyph(l *Scanner) stateFn {
+	r, _ := utf8.DecodeRuneInString(l.input[l.start:])
+	var text string
+	switch r {
+	case '¯':
+		// High minus, the sign of a negative number.
+		if next := l.peek(); next != '.' && !isNumeral(next, l.context.Config().InputBase()) {
+			return l.errorf("bad number syntax: %c", r)
+		}
+		l.replace(l.start, " -")
+		return lexAny
+	case '←':
+		text = "="
+	case '⋄':
+		text = ";"
+	case '⍝':
+		text = "#"
+	case '¨':
+		text = "each"
+	case '∘':
+		if l.peek() != '.' {
+			return l.errorf("no ivy operator for %c", r)
+		}
+		text = "o"
+	case '⌿', '⍀':
+		return l.errorf("no ivy operator for %c", r)
+	default:
+		binary := l.binaryContext()
+		text = glyphText(r, binary)
+		if text == "" {
+			return l.errorf("no %s ivy operator for %c", valence(binary), r)
+		}
+	}
+	text = " " + text
+	if !l.atCompound() {
+		text += " "
+	}
+	l.replace(l.start, text)
+	return lexAny
+}
+
+// glyphText returns the ivy spelling of the APL function glyph r,
+// or the empty string if ivy has no equivalent.
+func glyphText(r rune, binary bool) string {
+	if binary {
+		return glyphs[r].binary
+	}
+	return glyphs[r].unary
+}
+
+// valence returns the name of the operator kind for an error message.
+func valence(binary bool) string {
+	if binary {
+		return "binary"
+	}
+	return "unary"
+}
+
+// replace replaces the input from start up to the current position with
+// text, and backs up to start so the text is scanned next.
+func (l *Scanner) replace(start int, text string) {
+	l.input = l.input[:start] + text + l.input[l.pos:]
+	l.pos = start
+	l.width = 0
+}
+
+// atCompound reports whether the input is at the reduction, scan, or
+// product that follows the operator just scanned, as in +/ or +.*.
+func (l *Scanner) atCompound() bool {
+	switch l.peek() {
+	case '/', '\\', '⌿', '⍀':
+		return true
+	case '.':
+		r, _ := utf8.DecodeRuneInString(l.input[l.pos+1:])
+		return !isDigit(r)
+	}
+	return false
+}
+
+// binaryContext reports whether the APL function glyph just scanned
+// has a left operand. It does if it is the operator of a reduction, scan,
+// or product, or if the previous token ends an operand.
+func (l *Scanner) binaryContext() bool {
+	return l.atCompound() || l.endsOperand(l.last)
+}
+
+// endsOperand reports whether tok is the last token of an operand,
+// so an operator that follows it is binary.
+func (l *Scanner) endsOperand(tok Token) bool {
+	switch tok.Type {
+	case Number, Rational, String, RightParen, RightBrack:
+		return true
+	case Identifier:
+		word := tok.Text
+		return !exec.Predefined(word) && !l.context.UserDefined(word, false) && !l.context.UserDefined(word, true)
+	}
+	return false
+}
+
+// compoundGlyph rewrites an APL reduction or scan glyph, ⌿ or ⍀,
+// following an operator as its ivy spelling.
+func (l *Scanner) compoundGlyph() {
+	switch l.next() {
+	case '⌿':
+		l.replace(l.pos-l.width, "/%")
+	case '⍀':
+		l.replace(l.pos-l.width, `\%`)
+	default:
+		l.backup()
+	}
+}
+
+// productGlyph rewrites an APL function glyph that is the right operator
+// of an inner or outer product as the ivy spelling of its binary form.
+// It returns an error state if ivy has no equivalent, and nil otherwise.
+func (l *Scanner) productGlyph() stateFn {
+	r := l.next()
+	if _, ok := glyphs[r]; !ok {
+		l.backup()
+		return nil
+	}
+	text := glyphText(r, true)
+	if text == "" {
+		return l.errorf("no binary ivy operator for %c", r)
+	}
+	l.replace(l.pos-l.width, text+" ")
+	return nil
+}
+
+// highMinus rewrites APL's high minus as a minus sign, for the sign
+// of an exponent or imaginary part, as in 1e¯5 or 3j¯4.
+func (l *Scanner) highMinus() {
+	if l.next() == '¯' {
+		l.replace(l.pos-l.width, "-")
+	} else {
+		l.backup()
+	}
+}
+
+// aplGlyphs maps the ivy spellings of unary (index 0) and binary (index 1)
+// operators to the APL glyphs ToAPL writes for them.
+var aplGlyphs = [2]map[string]string{{}, {}}
+
+func init() {
+	for r, g := range glyphs {
+		switch r {
+		case '∼', '∈', '−':
+			// Alternate spellings; ToAPL writes ~, ∊, and ivy's -.
+			continue
+		}
+		for i, name := range []string{g.unary, g.binary} {
+			if name != "" && !strings.Contains(name, " ") {
+				aplGlyphs[i][name] = string(r)
+			}
+		}
+	}
+}
+
+// aplGlyph returns the APL glyph for the ivy operator name, or the name
+// itself if APL has no glyph for it.
+func aplGlyph(name string, binary bool) string {
+	i := 0
+	if binary {
+		i = 1
+	}
+	if g, ok := aplGlyphs[i][name]; ok {
+		return g
+	}
+	return name
+}
+
+// aplOperator returns the APL spelling of the operator token text,
+// which may include a reduction, scan, or product.
+func aplOperator(text string, binary bool) string {
+	if strings.HasPrefix(text, "{") {
+		return text // Anonymous op.
+	}
+	for _, suffix := range []string{"/%", `\%`, "/", `\`} {
+		if op := strings.TrimSuffix(text, suffix); op != text && op != "" {
+			switch suffix {
+			case "/%":
+				suffix = "⌿"
+			case `\%`:
+				suffix = "⍀"
+			}
+			return aplGlyph(op, true) + suffix
+		}
+	}
+	if i := strings.Index(text, "."); i > 0 {
+		left := "∘"
+		if text[:i] != "o" {
+			left = aplGlyph(text[:i], true)
+		}
+		return left + "." + aplGlyph(text[i+1:], true)
+	}
+	return aplGlyph(text, binary)
+}
+
+// ToAPL returns the ivy program text, such as a line printed by ProgString,
+// with operators written as their APL glyphs where APL has them. Negative
+// numbers are written with APL's high minus, rationals as parenthesized
+// divisions, assignment with ←, and the separator between statements with ⋄.
+// Everything else is left as it is.
+// Text that does not scan is returned unchanged.
+func ToAPL(context value.Context, text string) string {
+	l := New(context, "<apl>", strings.NewReader(text))
+	var b strings.Builder
+	var prev Token
+	var last spacing // Spacing of the previous token.
+	depth := 0       // Bracket depth; a semicolon inside brackets separates indexes.
+	header := false  // Whether in an op definition's header, where = is not assignment.
+	for tok := l.Next(); tok.Type != EOF; tok = l.Next() {
+		out := tok.Text
+		var sp spacing
+		switch tok.Type {
+		case Error:
+			return text
+		case Newline:
+			continue
+		case Number:
+			out = strings.Replace(out, "-", "¯", -1)
+		case Rational:
+			// APL has no rational constants, and would take a/b as
+			// a replication, so write a division.
+			out = "(" + strings.Replace(strings.Replace(out, "-", "¯", -1), "/", "÷", 1) + ")"
+		case Op:
+			header = true
+		case Assign:
+			if header {
+				header = false
+				sp.sep = true
+			} else {
+				out, sp.glyph = "←", true
+			}
+		case Colon:
+			sp.sep = true
+			// A colon starting a statement begins a keyword such as :while.
+			sp.open = b.Len() == 0 || prev.Type == Semicolon
+		case LeftParen:
+			sp.open = true
+		case LeftBrack:
+			depth++
+			sp.open, sp.close = true, true
+		case RightParen:
+			sp.close = true
+		case RightBrack:
+			depth--
+			sp.close = true
+		case Semicolon:
+			if depth == 0 && !header {
+				out, sp.sep = "⋄", true
+			} else {
+				sp.close = true
+			}
+		case Operator, Identifier:
+			if tok.Text == "each" {
+				out, sp.glyph = "¨", true
+				break
+			}
+			if tok.Type == Identifier && !exec.Predefined(tok.Text) {
+				break
+			}
+			out = aplOperator(tok.Text, l.endsOperand(prev))
+			sp.glyph = strings.IndexFunc(out, isAlphaNumeric) < 0
+		}
+		if b.Len() > 0 && !last.open && !sp.close && (last.sep || sp.sep || !last.glyph && !sp.glyph) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(out)
+		last = sp
+		prev = tok
+	}
+	return b.String()
+}
+
+// spacing describes how ToAPL spaces a token from its neighbors.
+type spacing struct {
+	glyph bool // An operator written without spaces.
+	sep   bool // A separator, always surrounded by spaces.
+	open  bool // An opening bracket, with no space after.
+	close bool // A closing bracket, with no space before.
+}
@@ -34,6 +34,7 @@ const (
 	// Interesting things
 	Assign         // '='
 	Char           // printable ASCII character; grab bag for comma etc.
+	Colon          // ':'
 	GreaterOrEqual // '>='
 	Identifier     // alphanumeric identifier
 	LeftBrack      // '['
@@ -82,6 +83,7 @@ type Scanner struct {
 	pos        int     // current position in the input
 	start      int     // start position of this item
 	width      int     // width of last rune read from input
+	last       Token   // last token emitted
 }
 
 // loadLine reads the next line of input and stores it in (appends it to) the input.
@@ -144,7 +146,8 @@ func (l *Scanner) emit(t Type) {
 	if config.Debug("tokens") {
 		fmt.Fprintf(config.Output(), "%s:%d: emit %s\n", l.name, l.line, Token{t, l.line, s})
 	}
-	l.tokens <- Token{t, l.line, s}
+	l.last = Token{t, l.line, s}
+	l.tokens <- l.last
 	l.start = l.pos
 	l.width = 0
 }
@@ -240,8 +243,13 @@ func lexAny(l *Scanner) stateFn {
 	case r == ';':
 		l.emit(Semicolon)
 		return lexAny
+	case r == ':':
+		l.emit(Colon)
+		return lexAny
 	case r == '#':
 		return lexComment
+	case r == '{':
+		return lexAnonymous
 	case isSpace(r):
 		return lexSpace
 	case r == '"':
@@ -291,6 +299,8 @@ func lexAny(l *Scanner) stateFn {
 	case r == ')':
 		l.emit(RightParen)
 		return lexAny
+	case isGlyph(r):
+		return lexGlyph
 	case r <= unicode.MaxASCII && unicode.IsPrint(r):
 		l.emit(Char)
 		return lexAny
@@ -349,13 +359,16 @@ func lexOperator(l *Scanner) stateFn {
 	// It might be an inner product or reduction, but only if it is a binary operator.
 	word := l.input[l.start:l.pos]
 	if word == "o" || value.BinaryOps[word] != nil || l.context.UserDefined(word, true) {
+		l.compoundGlyph()
 		switch l.peek() {
 		case '/':
-			// Reduction.
+			// Reduction, possibly along the first axis.
 			l.next()
+			l.accept("%")
 		case '\\':
-			// Scan.
+			// Scan, possibly along the first axis.
 			l.next()
+			l.accept("%")
 		case '.':
 			// Inner or outer product?
 			l.next()               // Accept the '.'.
@@ -365,8 +378,15 @@ func lexOperator(l *Scanner) stateFn {
 				return lexNumber // We know it starts ".7".
 			}
 			startRight := l.pos
+			if state := l.productGlyph(); state != nil {
+				return state
+			}
 			r := l.next()
 			switch {
+			case r == '{':
+				if !l.acceptBraces() {
+					return l.errorf("unterminated anonymous op")
+				}
 			case l.isOperator(r):
 			case isAlphaNumeric(r):
 				for isAlphaNumeric(r) {
@@ -391,6 +411,64 @@ func lexOperator(l *Scanner) stateFn {
 	return lexSpace
 }
 
+// lexAnonymous scans an anonymous op such as {a+b}, together with any
+// reduction, scan, or inner product operator that follows it, as in
+// {a+b}/ or {a+b}.*. The left brace has already been consumed.
+func lexAnonymous(l *Scanner) stateFn {
+	if !l.acceptBraces() {
+		return l.errorf("unterminated anonymous op")
+	}
+	l.compoundGlyph()
+	switch l.peek() {
+	case '/', '\\':
+		l.next()
+		l.accept("%")
+	case '.':
+		l.next()
+		startRight := l.pos
+		if state := l.productGlyph(); state != nil {
+			return state
+		}
+		r := l.next()
+		switch {
+		case r == '{':
+			if !l.acceptBraces() {
+				return l.errorf("unterminated anonymous op")
+			}
+		case l.isOperator(r):
+		case isAlphaNumeric(r):
+			for isAlphaNumeric(r) {
+				r = l.next()
+			}
+			l.backup()
+			word := l.input[startRight:l.pos]
+			if !exec.Predefined(word) && !l.context.UserDefined(word, true) {
+				return l.errorf("%s not an operator", word)
+			}
+		default:
+			return l.errorf("bad inner product: %s", l.input[l.start:l.pos])
+		}
+	}
+	l.emit(Operator)
+	return lexSpace
+}
+
+// acceptBraces consumes the text up to and including the right brace
+// matching the left brace just consumed. The text must all be on one line;
+// if it is not, acceptBraces consumes the rest of the line and returns false.
+func (l *Scanner) acceptBraces() bool {
+	n := value.BraceLen(l.input[l.pos-1:])
+	if n < 0 {
+		for r := l.peek(); r != eof && r != '\n'; r = l.peek() {
+			l.next()
+		}
+		l.ignore()
+		return false
+	}
+	l.pos += n - 1
+	return true
+}
+
 // atTerminator reports whether the input is at valid termination character to
 // appear after an identifier.
 func (l *Scanner) atTerminator() bool {
@@ -436,10 +514,12 @@ func lexNumber(l *Scanner) stateFn {
 	// Optional leading sign.
 	if l.accept("-") {
 		// Might not be a number.
+		l.compoundGlyph()
 		r := l.peek()
 		// Might be a scan or reduction.
 		if r == '/' || r == '\\' {
 			l.next()
+			l.accept("%")
 			l.emit(Operator)
 			return lexAny
 		}
@@ -452,8 +532,7 @@ func lexNumber(l *Scanner) stateFn {
 		return l.errorf("bad number syntax: %s", l.input[l.start:l.pos])
 	}
 	if l.peek() != '/' {
-		l.emit(Number)
-		return lexAny
+		return lexImaginary(l, Number)
 	}
 	// Might be a rational.
 	l.accept("/")
@@ -469,7 +548,35 @@ func lexNumber(l *Scanner) stateFn {
 	if !l.scanNumber() {
 		return l.errorf("bad number syntax: %s", l.input[l.start:l.pos])
 	}
-	l.emit(Rational)
+	return lexImaginary(l, Rational)
+}
+
+// lexImaginary scans the imaginary part, if any, of a complex number
+// such as 3j4 or 1/2j-3/4, and emits the number, which has type typ
+// unless the imaginary part is rational.
+func lexImaginary(l *Scanner, typ Type) stateFn {
+	if !l.accept("j") {
+		l.emit(typ)
+		return lexAny
+	}
+	l.highMinus()
+	l.accept("-")
+	if r := l.peek(); r != '.' && !isNumeral(r, l.context.Config().InputBase()) {
+		if isAlphaNumeric(r) {
+			l.next()
+		}
+		return l.errorf("bad number syntax: %s", l.input[l.start:l.pos])
+	}
+	if !l.scanNumber() {
+		return l.errorf("bad number syntax: %s", l.input[l.start:l.pos])
+	}
+	if l.accept("/") {
+		if !l.scanNumber() {
+			return l.errorf("bad number syntax: %s", l.input[l.start:l.pos])
+		}
+		typ = Rational
+	}
+	l.emit(typ)
 	return lexAny
 }
 
@@ -489,11 +596,13 @@ func (l *Scanner) scanNumber() bool {
 		l.acceptRun(digits)
 	}
 	if l.accept("eE") {
+		l.highMinus()
 		l.accept("+-")
 		l.acceptRun("0123456789")
 	}
-	// Next thing mustn't be alphanumeric except possibly an o for outer product (3o.+2).
-	if l.peek() != 'o' && isAlphaNumeric(l.peek()) {
+	// Next thing mustn't be alphanumeric except possibly an o for outer product (3o.+2)
+	// or a j for a complex number (3j4).
+	if r := l.peek(); r != 'o' && r != 'j' && isAlphaNumeric(r) {
 		l.next()
 		return false
 	}
@@ -650,10 +759,10 @@ func (l *Scanner) isOperator(r rune) bool {
 	case '?', '+', '-', '/', '%', '&', '|', '^', ',':
 		// No follow-on possible.
 	case '!':
-		if l.peek() != '=' {
-			return false
+		switch l.peek() {
+		case '=':
+			l.next()
 		}
-		l.next()
 	case '>':
 		switch l.peek() {
 		case '>', '=':
@@ -4,9 +4,9 @@ package scan
 
 import "fmt"
 
-const _Type_name = "EOFErrorNewlineAssignCharGreaterOrEqualIdentifierLeftBrackLeftParenNumberOperatorOpRationalRightBrackRightParenSemicolonSpaceString"
+const _Type_name = "EOFErrorNewlineAssignCharColonGreaterOrEqualIdentifierLeftBrackLeftParenNumberOperatorOpRationalRightBrackRightParenSemicolonSpaceString"
 
-var _Type_index = [...]uint8{0, 3, 8, 15, 21, 25, 39, 49, 58, 67, 73, 81, 83, 91, 101, 111, 120, 125, 131}
+var _Type_index = [...]uint8{0, 3, 8, 15, 21, 25, 30, 44, 54, 63, 72, 78, 86, 88, 96, 106, 116, 125, 130, 136}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
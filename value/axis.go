@@ -0,0 +1,414 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "strings"
+
+// Operators applied along an explicit axis, as in +/[1] m.
+
+// UnaryAxis evaluates the unary operator along the specified axis of v.
+// The axis is counted from the configured origin.
+func UnaryAxis(c Context, op string, axis Value, v Value) Value {
+	k := axisIndex(c, axis, rank(v))
+	switch {
+	case len(op) > 1 && strings.HasSuffix(op, "/"):
+		return ReduceAxis(c, op[:len(op)-1], k, v)
+	case len(op) > 1 && strings.HasSuffix(op, `\`):
+		return ScanAxis(c, op[:len(op)-1], k, v)
+	case op == "rot" || op == "flip":
+		if m, ok := v.(Matrix); ok {
+			return m.reverseAxis(k)
+		}
+		return c.EvalUnary(op, v)
+	}
+	Errorf("unary %s does not take an axis", op)
+	panic("not reached")
+}
+
+// BinaryAxis evaluates the binary operator along the specified axis of v,
+// or for catenation, of the operand of higher rank. The axis is counted
+// from the configured origin.
+func BinaryAxis(c Context, u Value, op string, axis Value, v Value) Value {
+	switch op {
+	case ",":
+		r := rank(u)
+		if rank(v) > r {
+			r = rank(v)
+		}
+		return catenateAxis(u, axisIndex(c, axis, r), v)
+	case "take", "drop", "rot", "flip":
+		k := axisIndex(c, axis, rank(v))
+		m, ok := v.(Matrix)
+		if !ok {
+			// Vectors and scalars have only one axis.
+			return c.EvalBinary(u, op, v)
+		}
+		n := axisCount(op, u)
+		switch op {
+		case "take":
+			return m.takeAxis(k, n)
+		case "drop":
+			return m.dropAxis(k, n)
+		}
+		return m.rotateAxis(k, n)
+	}
+	Errorf("binary %s does not take an axis", op)
+	panic("not reached")
+}
+
+// rank returns the number of axes of v: 0 for a scalar, 1 for a vector,
+// and the length of the shape for a matrix.
+func rank(v Value) int {
+	switch v := v.(type) {
+	case Vector:
+		return 1
+	case Matrix:
+		return len(v.shape)
+	}
+	return 0
+}
+
+// axisCount returns the count, which must be a small integer, for the
+// take, drop, rot, or flip along an axis.
+func axisCount(op string, u Value) int {
+	if v, ok := u.(Vector); ok && len(v) == 1 {
+		u = v[0]
+	}
+	n, ok := u.(Int)
+	if !ok {
+		Errorf("%s: count must be small integer", op)
+	}
+	return int(n)
+}
+
+// axisIndex converts the axis, counted from the origin, to an index into
+// a shape of the given rank, counted from 0.
+func axisIndex(c Context, axis Value, rank int) int {
+	if rank == 0 {
+		rank = 1 // A scalar acts as a vector.
+	}
+	origin := c.Config().Origin()
+	i, ok := axis.(Int)
+	if !ok || int(i) < origin || int(i) >= origin+rank {
+		Errorf("invalid axis %s for rank %d", axis, rank)
+	}
+	return int(i) - origin
+}
+
+// axisLayout returns the number of elements along the axis of the shape,
+// the number of blocks of elements before it, and the stride between
+// successive elements along it.
+func axisLayout(shape Vector, axis int) (n, outer, stride int) {
+	return int(shape[axis].(Int)), size(shape[:axis]), size(shape[axis+1:])
+}
+
+// ReduceAxis computes a reduction such as +/ along the specified axis,
+// counted from 0. ReduceAxis(c, op, 0, v) is the first-axis reduction +/%v.
+func ReduceAxis(c Context, op string, axis int, v Value) Value {
+	m, ok := v.(Matrix)
+	if !ok || axis == len(m.shape)-1 {
+		// Vectors and scalars have only one axis.
+		return Reduce(c, op, v)
+	}
+	n, outer, stride := axisLayout(m.shape, axis)
+	if n == 0 {
+		Errorf("shape for matrix is degenerate: %s", m.shape)
+	}
+	shape := make(Vector, 0, len(m.shape)-1)
+	shape = append(shape, m.shape[:axis]...)
+	shape = append(shape, m.shape[axis+1:]...)
+	data := make(Vector, outer*stride)
+	for o := 0; o < outer; o++ {
+		base := o * n * stride
+		for i := 0; i < stride; i++ {
+			// Right associative, as in Reduce.
+			acc := m.data[base+(n-1)*stride+i]
+			for j := n - 2; j >= 0; j-- {
+				acc = c.EvalBinary(m.data[base+j*stride+i], op, acc)
+			}
+			data[o*stride+i] = acc
+		}
+	}
+	if len(shape) == 1 {
+		return NewVector(data)
+	}
+	return NewMatrix(shape, data)
+}
+
+// ScanAxis computes a scan such as +\ along the specified axis,
+// counted from 0. ScanAxis(c, op, 0, v) is the first-axis scan +\%v.
+func ScanAxis(c Context, op string, axis int, v Value) Value {
+	m, ok := v.(Matrix)
+	if !ok || axis == len(m.shape)-1 {
+		// Vectors and scalars have only one axis.
+		return Scan(c, op, v)
+	}
+	n, outer, stride := axisLayout(m.shape, axis)
+	if n == 0 {
+		Errorf("shape for matrix is degenerate: %s", m.shape)
+	}
+	data := make(Vector, len(m.data))
+	for o := 0; o < outer; o++ {
+		base := o * n * stride
+		for i := 0; i < stride; i++ {
+			// TODO: This is n^2, as in Scan.
+			for j := 0; j < n; j++ {
+				acc := m.data[base+j*stride+i]
+				for k := j - 1; k >= 0; k-- {
+					acc = c.EvalBinary(m.data[base+k*stride+i], op, acc)
+				}
+				data[base+j*stride+i] = acc
+			}
+		}
+	}
+	return NewMatrix(m.shape, data)
+}
+
+// reverseAxis returns a copy of m with the elements reversed along the axis.
+func (m Matrix) reverseAxis(axis int) Value {
+	n, outer, stride := axisLayout(m.shape, axis)
+	data := make(Vector, len(m.data))
+	for o := 0; o < outer; o++ {
+		base := o * n * stride
+		for j := 0; j < n; j++ {
+			copy(data[base+j*stride:base+(j+1)*stride], m.data[base+(n-1-j)*stride:])
+		}
+	}
+	return NewMatrix(m.shape, data)
+}
+
+// rotateAxis returns a copy of m with the elements rotated by count
+// along the axis, as rot does along the last axis.
+func (m Matrix) rotateAxis(axis, count int) Value {
+	n, outer, stride := axisLayout(m.shape, axis)
+	if n == 0 {
+		return m
+	}
+	count %= n
+	if count < 0 {
+		count += n
+	}
+	data := make(Vector, len(m.data))
+	for o := 0; o < outer; o++ {
+		base := o * n * stride
+		for j := 0; j < n; j++ {
+			from := base + (j+count)%n*stride
+			copy(data[base+j*stride:base+(j+1)*stride], m.data[from:from+stride])
+		}
+	}
+	return NewMatrix(m.shape, data)
+}
+
+// takeAxis returns the first count elements of m along the axis,
+// or the last -count if count is negative.
+func (m Matrix) takeAxis(axis, count int) Value {
+	n := int(m.shape[axis].(Int))
+	if count > n || -count > n {
+		Errorf("bad count for take")
+	}
+	if count < 0 {
+		return m.sliceAxis(axis, n+count, -count)
+	}
+	return m.sliceAxis(axis, 0, count)
+}
+
+// dropAxis returns m without its first count elements along the axis,
+// or its last -count if count is negative.
+func (m Matrix) dropAxis(axis, count int) Value {
+	n := int(m.shape[axis].(Int))
+	if count > n || -count > n {
+		Errorf("bad count for drop")
+	}
+	if count < 0 {
+		return m.sliceAxis(axis, 0, n+count)
+	}
+	return m.sliceAxis(axis, count, n-count)
+}
+
+// sliceAxis returns the length elements of m starting at start along the axis.
+func (m Matrix) sliceAxis(axis, start, length int) Value {
+	n, outer, stride := axisLayout(m.shape, axis)
+	shape := make(Vector, len(m.shape))
+	copy(shape, m.shape)
+	shape[axis] = Int(length)
+	data := make(Vector, 0, outer*length*stride)
+	for o := 0; o < outer; o++ {
+		from := (o*n + start) * stride
+		data = append(data, m.data[from:from+length*stride]...)
+	}
+	return NewMatrix(shape, data)
+}
+
+// catenateAxis joins u and v along the axis, counted from 0, of the operand
+// of higher rank. An operand of rank one less is treated as having length
+// one along the axis, and a scalar is extended to fill that space.
+func catenateAxis(u Value, axis int, v Value) Value {
+	ushape, udata := shapeAndData(u)
+	vshape, vdata := shapeAndData(v)
+	r := len(ushape)
+	if len(vshape) > r {
+		r = len(vshape)
+	}
+	if r == 0 {
+		r = 1
+	}
+	ushape = catenateShape(ushape, vshape, r, axis)
+	vshape = catenateShape(vshape, ushape, r, axis)
+	for i := range ushape {
+		if i != axis && ushape[i] != vshape[i] {
+			Errorf("catenate shape mismatch: %s , %s", ushape, vshape)
+		}
+	}
+	un, outer, stride := axisLayout(ushape, axis)
+	vn := int(vshape[axis].(Int))
+	udata = extend(udata, size(ushape))
+	vdata = extend(vdata, size(vshape))
+	shape := make(Vector, r)
+	copy(shape, ushape)
+	shape[axis] = Int(un + vn)
+	data := make(Vector, 0, len(udata)+len(vdata))
+	for o := 0; o < outer; o++ {
+		data = append(data, udata[o*un*stride:(o+1)*un*stride]...)
+		data = append(data, vdata[o*vn*stride:(o+1)*vn*stride]...)
+	}
+	if r == 1 {
+		return NewVector(data)
+	}
+	return NewMatrix(shape, data)
+}
+
+// shapeAndData returns the shape and elements of v. A scalar has an empty shape.
+func shapeAndData(v Value) (Vector, Vector) {
+	switch v := v.(type) {
+	case Vector:
+		return Vector{Int(len(v))}, v
+	case Matrix:
+		return v.shape, v.data
+	}
+	return nil, Vector{v}
+}
+
+// catenateShape returns the shape of an operand of catenation along the
+// axis, given the shape of the other operand and the rank r of the result.
+func catenateShape(shape, other Vector, r, axis int) Vector {
+	switch len(shape) {
+	case r:
+		return shape
+	case 0:
+		// A scalar fills the other operand's shape, with length one along the axis.
+		s := make(Vector, r)
+		copy(s, other)
+		s[axis] = Int(1)
+		return s
+	case r - 1:
+		s := make(Vector, 0, r)
+		s = append(s, shape[:axis]...)
+		s = append(s, Int(1))
+		return append(s, shape[axis:]...)
+	}
+	Errorf("catenate rank mismatch: %s , %s", shape, other)
+	panic("not reached")
+}
+
+// extend returns data, a single element of which is repeated to fill n elements.
+func extend(data Vector, n int) Vector {
+	if len(data) != 1 || n == 1 {
+		return data
+	}
+	x := make(Vector, n)
+	for i := range x {
+		x[i] = data[0]
+	}
+	return x
+}
+
+// transposeAxes converts the left operand of transp, a vector of axes
+// counted from the origin, to a list of axes counted from 0. Element i
+// is the axis of the result to which axis i of the right operand moves.
+// Every axis of the result must be named at least once.
+func transposeAxes(c Context, u Value, rank int) []int {
+	shape, data := shapeAndData(u)
+	if len(shape) > 1 {
+		Errorf("transp: axes must be a vector")
+	}
+	if len(data) != rank {
+		Errorf("transp: %d axes for rank %d", len(data), rank)
+	}
+	origin := c.Config().Origin()
+	axes := make([]int, rank)
+	seen := make([]bool, rank)
+	n := 0
+	for i, a := range data {
+		j, ok := a.(Int)
+		if !ok || int(j) < origin || int(j) >= origin+rank {
+			Errorf("transp: invalid axis %s", a.Sprint(c.Config()))
+		}
+		axes[i] = int(j) - origin
+		seen[axes[i]] = true
+		if axes[i] >= n {
+			n = axes[i] + 1
+		}
+	}
+	for j := 0; j < n; j++ {
+		if !seen[j] {
+			Errorf("transp: missing axis %d", j+origin)
+		}
+	}
+	return axes
+}
+
+// transpose returns m with axis i moved to axis axes[i] of the result.
+// If several axes move to the same place, the result has the length of the
+// shortest of them there and takes the elements on their diagonal.
+func (m Matrix) transpose(axes []int) Value {
+	n := 0
+	for _, a := range axes {
+		if a >= n {
+			n = a + 1
+		}
+	}
+	shape := make([]int, n)
+	stride := make([]int, n) // Distance in m.data between successive elements along each result axis.
+	for j := range shape {
+		shape[j] = -1
+	}
+	s := 1
+	for i := len(axes) - 1; i >= 0; i-- {
+		a, length := axes[i], int(m.shape[i].(Int))
+		if shape[a] < 0 || length < shape[a] {
+			shape[a] = length
+		}
+		stride[a] += s
+		s *= length
+	}
+	size := 1
+	for _, length := range shape {
+		size *= length
+	}
+	data := make(Vector, size)
+	index := make([]int, n)
+	offset := 0
+	for k := range data {
+		data[k] = m.data[offset]
+		// Advance the index, last axis fastest.
+		for j := n - 1; j >= 0; j-- {
+			index[j]++
+			offset += stride[j]
+			if index[j] < shape[j] {
+				break
+			}
+			offset -= index[j] * stride[j]
+			index[j] = 0
+		}
+	}
+	if n == 1 {
+		return NewVector(data)
+	}
+	rshape := make(Vector, n)
+	for j, length := range shape {
+		rshape[j] = Int(length)
+	}
+	return NewMatrix(rshape, data)
+}
@@ -135,6 +135,10 @@ func (f BigFloat) toType(conf *config.Config, which valueType) Value {
 	switch which {
 	case bigFloatType:
 		return f
+	case complexType:
+		return Complex{f, zero}
+	case boxType:
+		return f
 	case vectorType:
 		return NewVector([]Value{f})
 	case matrixType:
@@ -151,6 +151,10 @@ func (i BigInt) toType(conf *config.Config, which valueType) Value {
 	case bigFloatType:
 		f := new(big.Float).SetPrec(conf.FloatPrec()).SetInt(i.Int)
 		return BigFloat{f}
+	case complexType:
+		return Complex{i, zero}
+	case boxType:
+		return i
 	case vectorType:
 		return NewVector([]Value{i})
 	case matrixType:
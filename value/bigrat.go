@@ -178,6 +178,10 @@ func (r BigRat) toType(conf *config.Config, which valueType) Value {
 	case bigFloatType:
 		f := new(big.Float).SetPrec(conf.FloatPrec()).SetRat(r.Rat)
 		return BigFloat{f}
+	case complexType:
+		return Complex{r, zero}
+	case boxType:
+		return r
 	case vectorType:
 		return NewVector([]Value{r})
 	case matrixType:
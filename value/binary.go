@@ -179,6 +179,9 @@ func init() {
 				bigFloatType: func(c Context, u, v Value) Value {
 					return binaryBigFloatOp(c, u, (*big.Float).Add, v)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return complexAdd(c, u.(Complex), v.(Complex))
+				},
 			},
 		},
 
@@ -201,6 +204,9 @@ func init() {
 				bigFloatType: func(c Context, u, v Value) Value {
 					return binaryBigFloatOp(c, u, (*big.Float).Sub, v)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return complexSub(c, u.(Complex), v.(Complex))
+				},
 			},
 		},
 
@@ -222,6 +228,9 @@ func init() {
 				bigFloatType: func(c Context, u, v Value) Value {
 					return binaryBigFloatOp(c, u, (*big.Float).Mul, v)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return complexMul(c, u.(Complex), v.(Complex))
+				},
 			},
 		},
 
@@ -239,6 +248,9 @@ func init() {
 				bigFloatType: func(c Context, u, v Value) Value {
 					return binaryBigFloatOp(c, u, (*big.Float).Quo, v)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return complexQuo(c, u.(Complex), v.(Complex))
+				},
 			},
 		},
 
@@ -373,6 +385,9 @@ func init() {
 					return z.shrink()
 				},
 				bigFloatType: func(c Context, u, v Value) Value { return power(c, u, v) },
+				complexType: func(c Context, u, v Value) Value {
+					return complexPower(c, u.(Complex), v.(Complex))
+				},
 			},
 		},
 
@@ -385,6 +400,72 @@ func init() {
 				bigIntType:   logBaseU,
 				bigRatType:   logBaseU,
 				bigFloatType: logBaseU,
+				complexType: func(c Context, u, v Value) Value {
+					return c.EvalBinary(c.EvalUnary("log", v), "/", c.EvalUnary("log", u))
+				},
+			},
+		},
+
+		{
+			name:        "gcd",
+			elementwise: true,
+			whichType:   binaryArithType,
+			fn: [numType]binaryFn{
+				intType:    gcd,
+				bigIntType: gcd,
+			},
+		},
+
+		{
+			name:        "lcm",
+			elementwise: true,
+			whichType:   binaryArithType,
+			fn: [numType]binaryFn{
+				intType:    lcm,
+				bigIntType: lcm,
+			},
+		},
+
+		{
+			name:        "modinv",
+			elementwise: true,
+			whichType:   binaryArithType,
+			fn: [numType]binaryFn{
+				intType:    modInv,
+				bigIntType: modInv,
+			},
+		},
+
+		{
+			name:      "modpow",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: modPow,
+			},
+		},
+
+		{
+			name:        "circle",
+			elementwise: true,
+			whichType:   binaryArithType,
+			fn: [numType]binaryFn{
+				intType:      circle,
+				bigIntType:   circle,
+				bigRatType:   circle,
+				bigFloatType: circle,
+				complexType:  circle,
+			},
+		},
+
+		{
+			name:        "!",
+			elementwise: true,
+			whichType:   binaryArithType,
+			fn: [numType]binaryFn{
+				intType:      binomial,
+				bigIntType:   binomial,
+				bigRatType:   binomial,
+				bigFloatType: binomial,
 			},
 		},
 
@@ -483,6 +564,12 @@ func init() {
 					i, j := u.(BigFloat), v.(BigFloat)
 					return toInt(i.Cmp(j.Float) == 0)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return toInt(complexEqual(c, u.(Complex), v.(Complex)))
+				},
+				boxType: func(c Context, u, v Value) Value {
+					return toInt(boxEqual(c, u, v))
+				},
 			},
 		},
 
@@ -509,6 +596,12 @@ func init() {
 					i, j := u.(BigFloat), v.(BigFloat)
 					return toInt(i.Cmp(j.Float) != 0)
 				},
+				complexType: func(c Context, u, v Value) Value {
+					return toInt(!complexEqual(c, u.(Complex), v.(Complex)))
+				},
+				boxType: func(c Context, u, v Value) Value {
+					return toInt(!boxEqual(c, u, v))
+				},
 			},
 		},
 
@@ -841,6 +934,24 @@ func init() {
 			},
 		},
 
+		{
+			name:      "text",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: formatText,
+				matrixType: formatText,
+			},
+		},
+
+		{
+			name:      "?",
+			whichType: binaryArithType,
+			fn: [numType]binaryFn{
+				intType:    deal,
+				bigIntType: bigDeal,
+			},
+		},
+
 		{
 			name: "in",
 			// A in B: Membership: 0 or 1 according to which elements of A present in B.
@@ -855,6 +966,36 @@ func init() {
 			},
 		},
 
+		{
+			name:      "union",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return union(c, u.(Vector), v.(Vector))
+				},
+			},
+		},
+
+		{
+			name:      "intersect",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return intersect(c, u.(Vector), v.(Vector))
+				},
+			},
+		},
+
+		{
+			name:      "without",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					return without(c, u.(Vector), v.(Vector))
+				},
+			},
+		},
+
 		{
 			name:      "[]",
 			whichType: binaryArithType,
@@ -1200,6 +1341,21 @@ func init() {
 			},
 		},
 
+		{
+			name:      "transp",
+			whichType: atLeastVectorType,
+			fn: [numType]binaryFn{
+				vectorType: func(c Context, u, v Value) Value {
+					transposeAxes(c, u, 1)
+					return v
+				},
+				matrixType: func(c Context, u, v Value) Value {
+					m := v.(Matrix)
+					return m.transpose(transposeAxes(c, u, len(m.shape)))
+				},
+			},
+		},
+
 		{
 			name:      "fill",
 			whichType: atLeastVectorType,
@@ -1316,6 +1472,20 @@ func init() {
 				},
 			},
 		},
+
+		{
+			name:      "mdiv",
+			whichType: binaryArithType,
+			fn: [numType]binaryFn{
+				intType:      matrixDivide,
+				bigIntType:   matrixDivide,
+				bigRatType:   matrixDivide,
+				bigFloatType: matrixDivide,
+				complexType:  matrixDivide,
+				vectorType:   matrixDivide,
+				matrixType:   matrixDivide,
+			},
+		},
 	}
 
 	for _, op := range ops {
@@ -0,0 +1,101 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"robpike.io/ivy/config"
+)
+
+// Box is an enclosed value: a vector or matrix that is treated as a scalar,
+// so it can be an element of another vector or matrix. It is created by
+// the unary op box and opened by unbox.
+type Box struct {
+	value Value
+}
+
+// NewBox returns v enclosed in a Box. A scalar that is not a Box is
+// returned unchanged, as enclosing it would have no effect.
+func NewBox(v Value) Value {
+	switch v.(type) {
+	case Vector, Matrix, Box:
+		return Box{v}
+	}
+	return v
+}
+
+// Unbox returns the contents of the Box.
+func (b Box) Unbox() Value {
+	return b.value
+}
+
+func (b Box) String() string {
+	return b.Sprint(debugConf)
+}
+
+// Sprint prints the contents of the box in parentheses. A matrix
+// is shown in the form "shape rho data" to keep it on one line.
+func (b Box) Sprint(conf *config.Config) string {
+	if m, ok := b.value.(Matrix); ok {
+		return "(" + m.shape.Sprint(conf) + " rho " + m.data.Sprint(conf) + ")"
+	}
+	return "(" + b.value.Sprint(conf) + ")"
+}
+
+func (b Box) ProgString() string {
+	// There is no such thing as a box in program listings; it
+	// is created by evaluating the box operator.
+	panic("box.ProgString - cannot happen")
+}
+
+func (b Box) Eval(Context) Value {
+	return b
+}
+
+func (b Box) Inner() Value {
+	return b
+}
+
+func (b Box) toType(conf *config.Config, which valueType) Value {
+	switch which {
+	case boxType:
+		return b
+	case vectorType:
+		return NewVector([]Value{b})
+	case matrixType:
+		return NewMatrix([]Value{one}, []Value{b})
+	}
+	Errorf("cannot convert box to %s", which)
+	return nil
+}
+
+// boxEqual reports whether u and v, each a Box or a scalar, have the same
+// contents: the same shape and equal elements. A scalar converts to the
+// box type as itself, just as boxing it has no effect.
+func boxEqual(c Context, u, v Value) bool {
+	ushape, udata := shapeAndData(unbox(u))
+	vshape, vdata := shapeAndData(unbox(v))
+	if len(ushape) != len(vshape) || len(udata) != len(vdata) {
+		return false
+	}
+	for i := range ushape {
+		if ushape[i] != vshape[i] {
+			return false
+		}
+	}
+	for i := range udata {
+		if !toBool(c.EvalBinary(udata[i], "==", vdata[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// unbox returns the contents of v if it is a Box, and otherwise v itself.
+func unbox(v Value) Value {
+	if b, ok := v.(Box); ok {
+		return b.value
+	}
+	return v
+}
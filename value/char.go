@@ -45,6 +45,8 @@ func (c Char) toType(conf *config.Config, which valueType) Value {
 	switch which {
 	case charType:
 		return c
+	case boxType:
+		return c
 	case vectorType:
 		return NewVector([]Value{c})
 	case matrixType:
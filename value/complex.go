@@ -0,0 +1,313 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math/big"
+
+	"robpike.io/ivy/config"
+)
+
+// Complex is a complex number, written 3j4 for 3+4i. Its parts are
+// real scalars: exact rationals (Int, BigInt or BigRat) when the number
+// arises from exact arithmetic, and BigFloats when it is the result of
+// a transcendental function such as sqrt or log.
+type Complex struct {
+	real Value
+	imag Value
+}
+
+// newComplex returns the complex number re+im*i. If the imaginary
+// part is zero, it returns the real part instead.
+func newComplex(re, im Value) Value {
+	if !toBool(im) {
+		return re
+	}
+	return Complex{re, im}
+}
+
+// floatComplex returns the complex number with the specified
+// floating-point parts, each shrunk to an integer if possible.
+func floatComplex(re, im *big.Float) Value {
+	return newComplex(BigFloat{re}.shrink(), BigFloat{im}.shrink())
+}
+
+// Real returns the real part of z.
+func (z Complex) Real() Value {
+	return z.real
+}
+
+// Imag returns the imaginary part of z.
+func (z Complex) Imag() Value {
+	return z.imag
+}
+
+func (z Complex) String() string {
+	return "(" + z.Sprint(debugConf) + ")"
+}
+
+func (z Complex) Sprint(conf *config.Config) string {
+	return z.real.Sprint(conf) + "j" + z.imag.Sprint(conf)
+}
+
+func (z Complex) ProgString() string {
+	return z.real.ProgString() + "j" + z.imag.ProgString()
+}
+
+func (z Complex) Eval(Context) Value {
+	return z
+}
+
+func (z Complex) Inner() Value {
+	return z
+}
+
+func (z Complex) toType(conf *config.Config, which valueType) Value {
+	switch which {
+	case complexType:
+		return z
+	case boxType:
+		return z
+	case vectorType:
+		return NewVector([]Value{z})
+	case matrixType:
+		return NewMatrix([]Value{one}, []Value{z})
+	}
+	Errorf("cannot convert complex to %s", which)
+	return nil
+}
+
+// floats returns the parts of z as BigFloats.
+func (z Complex) floats(c Context) (re, im *big.Float) {
+	return floatSelf(c, z.real).(BigFloat).Float, floatSelf(c, z.imag).(BigFloat).Float
+}
+
+// isZero reports whether z is zero.
+func (z Complex) isZero() bool {
+	return !toBool(z.real) && !toBool(z.imag)
+}
+
+// complexAdd returns u+v.
+func complexAdd(c Context, u, v Complex) Value {
+	return newComplex(c.EvalBinary(u.real, "+", v.real), c.EvalBinary(u.imag, "+", v.imag))
+}
+
+// complexSub returns u-v.
+func complexSub(c Context, u, v Complex) Value {
+	return newComplex(c.EvalBinary(u.real, "-", v.real), c.EvalBinary(u.imag, "-", v.imag))
+}
+
+// complexMul returns u*v.
+func complexMul(c Context, u, v Complex) Value {
+	// (a+bi)(c+di) = (ac-bd) + (ad+bc)i
+	re := c.EvalBinary(c.EvalBinary(u.real, "*", v.real), "-", c.EvalBinary(u.imag, "*", v.imag))
+	im := c.EvalBinary(c.EvalBinary(u.real, "*", v.imag), "+", c.EvalBinary(u.imag, "*", v.real))
+	return newComplex(re, im)
+}
+
+// complexQuo returns u/v.
+func complexQuo(c Context, u, v Complex) Value {
+	if v.isZero() {
+		Errorf("division by zero")
+	}
+	// (a+bi)/(c+di) = ((ac+bd) + (bc-ad)i) / (c²+d²)
+	den := c.EvalBinary(c.EvalBinary(v.real, "*", v.real), "+", c.EvalBinary(v.imag, "*", v.imag))
+	re := c.EvalBinary(c.EvalBinary(u.real, "*", v.real), "+", c.EvalBinary(u.imag, "*", v.imag))
+	im := c.EvalBinary(c.EvalBinary(u.imag, "*", v.real), "-", c.EvalBinary(u.real, "*", v.imag))
+	return newComplex(c.EvalBinary(re, "/", den), c.EvalBinary(im, "/", den))
+}
+
+// complexEqual reports whether u and v are equal.
+func complexEqual(c Context, u, v Complex) bool {
+	return toBool(c.EvalBinary(u.real, "==", v.real)) && toBool(c.EvalBinary(u.imag, "==", v.imag))
+}
+
+// complexAbs returns the magnitude of z.
+func complexAbs(c Context, z Complex) Value {
+	return sqrt(c, c.EvalBinary(c.EvalBinary(z.real, "*", z.real), "+", c.EvalBinary(z.imag, "*", z.imag)))
+}
+
+// complexPower returns u**v. Integer powers are computed exactly by
+// repeated squaring; others as exp(v*log u).
+func complexPower(c Context, u, v Complex) Value {
+	if !toBool(v.imag) {
+		if exp, ok := v.real.(Int); ok {
+			return complexIntPower(c, u, exp)
+		}
+	}
+	if u.isZero() {
+		if toBool(c.EvalBinary(v.real, ">", zero)) {
+			return zero
+		}
+		Errorf("negative exponent of zero")
+	}
+	return c.EvalUnary("**", c.EvalBinary(v, "*", complexLog(c, u)))
+}
+
+// complexIntPower returns z**exp for integer exp.
+func complexIntPower(c Context, z Complex, exp Int) Value {
+	if exp < 0 {
+		if z.isZero() {
+			Errorf("negative exponent of zero")
+		}
+		return c.EvalUnary("/", complexIntPower(c, z, -exp))
+	}
+	var result Value = one
+	var x Value = z
+	// For each loop, we compute x**n where n is a power of two.
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = c.EvalBinary(result, "*", x)
+		}
+		x = c.EvalBinary(x, "*", x)
+		exp >>= 1
+	}
+	return result
+}
+
+// complexSqrt returns the principal square root of z.
+func complexSqrt(c Context, z Complex) Value {
+	// sqrt(a+bi) = sqrt((r+a)/2) + sgn(b)*sqrt((r-a)/2)i where r = |a+bi|.
+	a, b := z.floats(c)
+	r := floatHypot(c, a, b)
+	re := newFloat(c).Add(r, a)
+	re.Quo(re, floatTwo)
+	im := newFloat(c).Sub(r, a)
+	im.Quo(im, floatTwo)
+	// Rounding error could make a tiny part negative.
+	if re.Sign() < 0 {
+		re.SetInt64(0)
+	}
+	if im.Sign() < 0 {
+		im.SetInt64(0)
+	}
+	re = floatSqrt(c, re)
+	im = floatSqrt(c, im)
+	if b.Sign() < 0 {
+		im.Neg(im)
+	}
+	return floatComplex(re, im)
+}
+
+// complexLog returns the principal natural logarithm of z.
+func complexLog(c Context, z Complex) Value {
+	// log(a+bi) = log(r) + θi, where r and θ are the polar coordinates of a+bi.
+	a, b := z.floats(c)
+	if a.Sign() == 0 && b.Sign() == 0 {
+		Errorf("log of zero")
+	}
+	return floatComplex(floatLog(c, floatHypot(c, a, b)), floatAtan2(c, b, a))
+}
+
+// complexExp returns e**z.
+func complexExp(c Context, z Complex) Value {
+	// e**(a+bi) = e**a * (cos b + i sin b).
+	a, b := z.floats(c)
+	ea := exponential(c.Config(), a)
+	re := floatCos(c, newFloat(c).Set(b))
+	im := floatSin(c, newFloat(c).Set(b))
+	return floatComplex(re.Mul(re, ea), im.Mul(im, ea))
+}
+
+// complexSin returns sin z.
+func complexSin(c Context, z Complex) Value {
+	// sin(a+bi) = sin a cosh b + i cos a sinh b.
+	a, b := z.floats(c)
+	sinh, cosh := floatSinhCosh(c, b)
+	re := floatSin(c, newFloat(c).Set(a))
+	im := floatCos(c, newFloat(c).Set(a))
+	return floatComplex(re.Mul(re, cosh), im.Mul(im, sinh))
+}
+
+// complexCos returns cos z.
+func complexCos(c Context, z Complex) Value {
+	// cos(a+bi) = cos a cosh b - i sin a sinh b.
+	a, b := z.floats(c)
+	sinh, cosh := floatSinhCosh(c, b)
+	re := floatCos(c, newFloat(c).Set(a))
+	im := floatSin(c, newFloat(c).Set(a))
+	im.Mul(im, sinh)
+	return floatComplex(re.Mul(re, cosh), im.Neg(im))
+}
+
+// complexTan returns tan z.
+func complexTan(c Context, z Complex) Value {
+	cos := complexCos(c, z)
+	if !toBool(c.EvalBinary(cos, "!=", zero)) {
+		Errorf("tangent is infinite")
+	}
+	return c.EvalBinary(complexSin(c, z), "/", cos)
+}
+
+var imaginaryOne = Complex{zero, one}
+
+// complexAsin returns the principal arcsine of z.
+func complexAsin(c Context, z Complex) Value {
+	// asin z = -i log(iz + sqrt(1-z²)).
+	root := c.EvalUnary("sqrt", c.EvalBinary(one, "-", c.EvalBinary(z, "*", z)))
+	x := c.EvalBinary(c.EvalBinary(imaginaryOne, "*", z), "+", root)
+	return c.EvalBinary(Complex{zero, minusOne}, "*", c.EvalUnary("log", x))
+}
+
+// complexAcos returns the principal arccosine of z.
+func complexAcos(c Context, z Complex) Value {
+	// acos z = π/2 - asin z.
+	halfPi := newFloat(c).Set(floatPi)
+	halfPi.Quo(halfPi, floatTwo)
+	return c.EvalBinary(BigFloat{halfPi}, "-", complexAsin(c, z))
+}
+
+// complexAtan returns the principal arctangent of z.
+func complexAtan(c Context, z Complex) Value {
+	// atan z = i/2 log((i+z)/(i-z)).
+	if complexEqual(c, z, imaginaryOne) || complexEqual(c, z, Complex{zero, minusOne}) {
+		Errorf("arctangent is infinite")
+	}
+	x := c.EvalBinary(c.EvalBinary(imaginaryOne, "+", z), "/", c.EvalBinary(imaginaryOne, "-", z))
+	return c.EvalBinary(Complex{zero, BigRat{big.NewRat(1, 2)}}, "*", c.EvalUnary("log", x))
+}
+
+// floatHypot returns sqrt(a²+b²).
+func floatHypot(c Context, a, b *big.Float) *big.Float {
+	z := newFloat(c).Mul(a, a)
+	z.Add(z, newFloat(c).Mul(b, b))
+	return floatSqrt(c, z)
+}
+
+// floatAtan2 returns the arctangent of y/x, using the signs of the two
+// to determine the quadrant of the result, which is in the range [-π, π].
+func floatAtan2(c Context, y, x *big.Float) *big.Float {
+	if x.Sign() == 0 {
+		z := newFloat(c)
+		if y.Sign() == 0 {
+			return z
+		}
+		z.Quo(floatPi, floatTwo)
+		if y.Sign() < 0 {
+			z.Neg(z)
+		}
+		return z
+	}
+	z := floatAtan(c, newFloat(c).Quo(y, x))
+	if x.Sign() < 0 {
+		if y.Sign() < 0 {
+			z.Sub(z, floatPi)
+		} else {
+			z.Add(z, floatPi)
+		}
+	}
+	return z
+}
+
+// floatSinhCosh returns sinh x and cosh x.
+func floatSinhCosh(c Context, x *big.Float) (sinh, cosh *big.Float) {
+	ex := exponential(c.Config(), x)
+	emx := newFloat(c).Quo(floatOne, ex)
+	sinh = newFloat(c).Sub(ex, emx)
+	sinh.Quo(sinh, floatTwo)
+	cosh = newFloat(c).Add(ex, emx)
+	cosh.Quo(cosh, floatTwo)
+	return sinh, cosh
+}
@@ -18,6 +18,28 @@ type Expr interface {
 	Eval(Context) Value
 }
 
+// Decomposable allows one to pull apart a parsed expression.
+// Only implemented by Expr types that need to be decomposed
+// in function evaluation.
+type Decomposable interface {
+	// Operator returns the name of the expression's operator.
+	Operator() string
+
+	// Operands returns the left and right operands, or nil if absent.
+	Operands() (left, right Expr)
+}
+
+// Loop allows one to pull apart a parsed loop statement.
+// Only implemented by Expr types that represent loops
+// in function bodies.
+type Loop interface {
+	// Condition returns the expression that controls the loop.
+	Condition() Expr
+
+	// Body returns the statements executed on each iteration.
+	Body() []Expr
+}
+
 // UnaryOp is the interface implemented by a simple unary operator.
 type UnaryOp interface {
 	EvalUnary(c Context, right Value) Value
@@ -14,12 +14,14 @@ const (
 	bigIntType
 	bigRatType
 	bigFloatType
+	complexType
+	boxType
 	vectorType
 	matrixType
 	numType
 )
 
-var typeName = [...]string{"int", "char", "big int", "rational", "float", "vector", "matrix"}
+var typeName = [...]string{"int", "char", "big int", "rational", "float", "complex", "box", "vector", "matrix"}
 
 func (t valueType) String() string {
 	return typeName[t]
@@ -71,6 +73,10 @@ func whichType(v Value) valueType {
 		return bigRatType
 	case BigFloat:
 		return bigFloatType
+	case Complex:
+		return complexType
+	case Box:
+		return boxType
 	case Vector:
 		return vectorType
 	case Matrix:
@@ -106,6 +112,10 @@ func (op *binaryOp) EvalBinary(c Context, u, v Value) Value {
 // they must both be vectors.
 func Product(c Context, u Value, op string, v Value) Value {
 	dot := strings.IndexByte(op, '.')
+	if strings.HasPrefix(op, "{") {
+		// The left operator is anonymous; skip any periods inside it.
+		dot = BraceLen(op)
+	}
 	left := op[:dot]
 	right := op[dot+1:]
 	which := atLeastVectorType(whichType(u), whichType(v))
@@ -117,6 +127,35 @@ func Product(c Context, u Value, op string, v Value) Value {
 	return innerProduct(c, u, left, right, v)
 }
 
+// BraceLen returns the length of the text enclosed in matching braces,
+// such as the anonymous op "{a+b}", at the start of s. Braces inside quoted
+// strings and characters do not count. If the braces do not match, BraceLen
+// returns -1.
+func BraceLen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '"', '\'', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		}
+		if depth == 0 {
+			return -1
+		}
+	}
+	return -1
+}
+
 // inner product computes an inner product such as "+.*".
 // u and v are known to be the same type and at least Vectors.
 func innerProduct(c Context, u Value, left, right string, v Value) Value {
@@ -303,6 +342,78 @@ func Scan(c Context, op string, v Value) Value {
 	panic("not reached")
 }
 
+// Each applies the unary op to each element of v; the "each" has been removed.
+// It lets an op written for scalars, such as a user-defined op, be mapped
+// over a vector or matrix. Boxed elements are opened before the op is applied,
+// and results that are not scalars are boxed.
+func Each(c Context, op string, v Value) Value {
+	switch v.(type) {
+	case Vector:
+		return eachResult(unaryVectorOp(c, op, openBoxes(v)))
+	case Matrix:
+		return eachResult(unaryMatrixOp(c, op, openBoxes(v)))
+	}
+	return eachElem(c.EvalUnary(op, unbox(v)))
+}
+
+// EachBinary applies the binary op to corresponding elements of u and v;
+// the "each" has been removed. As with the elementwise builtin ops, a scalar
+// or single-element operand is extended to match the other.
+func EachBinary(c Context, u Value, op string, v Value) Value {
+	ut, vt := whichType(u), whichType(v)
+	if ut < vectorType && vt < vectorType {
+		return eachElem(c.EvalBinary(unbox(u), op, unbox(v)))
+	}
+	which := atLeastVectorType(ut, vt)
+	u = openBoxes(u.toType(c.Config(), which))
+	v = openBoxes(v.toType(c.Config(), which))
+	if which == vectorType {
+		return eachResult(binaryVectorOp(c, u, op, v))
+	}
+	return eachResult(binaryMatrixOp(c, u, op, v))
+}
+
+// openBoxes returns a copy of the vector or matrix v with any boxed
+// elements replaced by their contents. The result is only for use
+// inside each, as its elements may not be scalars.
+func openBoxes(v Value) Value {
+	switch v := v.(type) {
+	case Vector:
+		n := make(Vector, len(v))
+		for i, x := range v {
+			n[i] = unbox(x)
+		}
+		return n
+	case Matrix:
+		return Matrix{shape: v.shape, data: openBoxes(v.data).(Vector)}
+	}
+	return v
+}
+
+// eachResult boxes the elements of the result of each that are not scalars.
+func eachResult(v Value) Value {
+	var data Vector
+	switch v := v.(type) {
+	case Vector:
+		data = v
+	case Matrix:
+		data = v.data
+	}
+	for i, x := range data {
+		data[i] = eachElem(x)
+	}
+	return v
+}
+
+// eachElem returns x as an element of the result of each: a single-element
+// vector is taken to be its element, and other vectors and matrices are boxed.
+func eachElem(x Value) Value {
+	if x, ok := x.(Vector); ok && len(x) == 1 {
+		return x[0]
+	}
+	return NewBox(x)
+}
+
 // unaryVectorOp applies op elementwise to i.
 func unaryVectorOp(c Context, op string, i Value) Value {
 	u := i.(Vector)
@@ -0,0 +1,217 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// factorial returns v!. It is exact for non-negative integers;
+// for other numbers it is gamma(v+1).
+func factorial(c Context, v Value) Value {
+	if n, ok := bigInteger(v); ok {
+		if n.Sign() < 0 {
+			Errorf("factorial of negative integer")
+		}
+		return intFactorial(c, n)
+	}
+	return gamma(c, c.EvalBinary(v, "+", one))
+}
+
+// gamma returns the gamma function of v, which for a positive integer
+// is (v-1)!.
+func gamma(c Context, v Value) Value {
+	if n, ok := bigInteger(v); ok {
+		if n.Sign() <= 0 {
+			Errorf("gamma of non-positive integer")
+		}
+		return intFactorial(c, n.Sub(n, big.NewInt(1)))
+	}
+	return evalFloatFunc(c, v, floatGamma)
+}
+
+// binomial returns the number of combinations of n taken k at a time.
+// For integers it is exact and follows APL's rules for negative arguments;
+// otherwise it is gamma(n+1)/(gamma(k+1)*gamma(n-k+1)).
+func binomial(c Context, k, n Value) Value {
+	bk, kok := bigInteger(k)
+	bn, nok := bigInteger(n)
+	if kok && nok {
+		return intBinomial(c, bk, bn)
+	}
+	fk := floatSelf(c, k).(BigFloat).Float
+	fn := floatSelf(c, n).(BigFloat).Float
+	n1 := newFloat(c).Add(fn, floatOne)
+	if isPole(n1) {
+		Errorf("binomial coefficient is infinite")
+	}
+	k1 := newFloat(c).Add(fk, floatOne)
+	nk1 := newFloat(c).Sub(n1, fk)
+	if isPole(k1) || isPole(nk1) {
+		return zero
+	}
+	z := floatGamma(c, n1)
+	z.Quo(z, floatGamma(c, k1))
+	z.Quo(z, floatGamma(c, nk1))
+	return BigFloat{z}.shrink()
+}
+
+// intBinomial returns the binomial coefficient of the integers k and n.
+func intBinomial(c Context, k, n *big.Int) Value {
+	switch {
+	case n.Sign() >= 0:
+		if k.Sign() < 0 || k.Cmp(n) > 0 {
+			return zero
+		}
+	case k.Sign() >= 0:
+		// (-1)**k times k!(k-n-1).
+		m := new(big.Int).Sub(k, n)
+		z := intBinomial(c, k, m.Sub(m, big.NewInt(1)))
+		if k.Bit(0) == 1 {
+			z = c.EvalUnary("-", z)
+		}
+		return z
+	default:
+		// (-1)**(n-k) times (-n-1)!(-k-1), which is zero if k > n.
+		nk := new(big.Int).Sub(n, k)
+		m := new(big.Int).Neg(n)
+		j := new(big.Int).Neg(k)
+		z := intBinomial(c, m.Sub(m, big.NewInt(1)), j.Sub(j, big.NewInt(1)))
+		if nk.Bit(0) == 1 {
+			z = c.EvalUnary("-", z)
+		}
+		return z
+	}
+	if !n.IsInt64() {
+		Errorf("binomial coefficient too large")
+	}
+	nn, kk := n.Int64(), k.Int64()
+	bits := (lgamma(nn+1) - lgamma(kk+1) - lgamma(nn-kk+1)) / math.Ln2
+	mustFit(c.Config(), int64(bits))
+	return BigInt{new(big.Int).Binomial(nn, kk)}.shrink()
+}
+
+// intFactorial returns n! for a non-negative integer n.
+func intFactorial(c Context, n *big.Int) Value {
+	if !n.IsInt64() {
+		Errorf("factorial too large")
+	}
+	mustFit(c.Config(), int64(lgamma(n.Int64()+1)/math.Ln2))
+	return BigInt{new(big.Int).MulRange(1, n.Int64())}.shrink()
+}
+
+// lgamma returns the natural logarithm of gamma(x), as a float64.
+// It is used to estimate the size of a result before computing it.
+func lgamma(x int64) float64 {
+	lg, _ := math.Lgamma(float64(x))
+	return lg
+}
+
+// bigInteger returns the value of v as a big.Int, if v is an integer.
+func bigInteger(v Value) (*big.Int, bool) {
+	switch v := v.(type) {
+	case Int:
+		return big.NewInt(int64(v)), true
+	case BigInt:
+		return new(big.Int).Set(v.Int), true
+	case BigRat:
+		if v.IsInt() {
+			return new(big.Int).Set(v.Num()), true
+		}
+	case BigFloat:
+		if v.IsInt() {
+			i, _ := v.Int(nil)
+			return i, true
+		}
+	}
+	return nil, false
+}
+
+// isPole reports whether x is a non-positive integer, where gamma is infinite.
+func isPole(x *big.Float) bool {
+	return x.Sign() <= 0 && x.IsInt()
+}
+
+// floatGamma computes gamma(x) using Stirling's series after shifting x
+// upwards, where the series converges well, and the reflection formula
+// for x < 1/2.
+func floatGamma(c Context, x *big.Float) *big.Float {
+	if isPole(x) {
+		Errorf("gamma of non-positive integer")
+	}
+	if x.MantExp(nil) > 60 {
+		Errorf("gamma argument too large")
+	}
+	half := newFloat(c).SetFloat64(0.5)
+	if x.Cmp(half) < 0 {
+		// gamma(x) = π / (sin(πx) gamma(1-x)).
+		s := newFloat(c).Mul(floatPi, x)
+		s = floatSin(c, s)
+		s.Mul(s, floatGamma(c, newFloat(c).Sub(floatOne, x)))
+		return s.Quo(floatPi, s)
+	}
+	// The error in the series for y is about e**-2πy, so y must be at least
+	// about prec/9 to get full precision. Shift x up by n to y, and use
+	// gamma(x) = gamma(x+n) / x(x+1)...(x+n-1).
+	y := newFloat(c).Set(x)
+	shift := newFloat(c).SetInt64(1)
+	minY := newFloat(c).SetInt64(int64(c.Config().FloatPrec()/8 + 8))
+	for y.Cmp(minY) < 0 {
+		shift.Mul(shift, y)
+		y.Add(y, floatOne)
+	}
+	// log gamma(y) = (y-1/2)log y - y + log(2π)/2 + Σ B₂ₖ/(2k(2k-1)y**(2k-1)).
+	// To avoid the exponential of a large number, we compute
+	// gamma(y) = y**(y-1/2) e**-y sqrt(2π) e**sum.
+	sum := newFloat(c)
+	yN := newFloat(c).Set(y) // y**(2k-1)
+	y2 := newFloat(c).Mul(y, y)
+	term := newFloat(c)
+	den := newFloat(c)
+	for k, loop := 1, newLoop(c.Config(), "gamma", x, 1); ; k++ {
+		term.SetRat(bernoulli(2 * k))
+		den.SetInt64(int64(2 * k * (2*k - 1)))
+		den.Mul(den, yN)
+		term.Quo(term, den)
+		sum.Add(sum, term)
+		if loop.done(sum) {
+			break
+		}
+		yN.Mul(yN, y2)
+	}
+	z := floatPower(c, BigFloat{y}, BigFloat{newFloat(c).Sub(y, half)})
+	z.Quo(z, floatExp(c, y))
+	twoPi := newFloat(c).Mul(floatTwo, floatPi)
+	z.Mul(z, floatSqrt(c, twoPi))
+	z.Mul(z, exponential(c.Config(), sum))
+	return z.Quo(z, shift)
+}
+
+// The Bernoulli numbers are computed as needed by the Akiyama-Tanigawa
+// algorithm and cached.
+var bernoulliCache struct {
+	sync.Mutex
+	a []*big.Rat // The working row of the algorithm.
+	b []*big.Rat // B₀, B₁, ...
+}
+
+// bernoulli returns the Bernoulli number Bₙ. The caller must not modify it.
+func bernoulli(n int) *big.Rat {
+	cache := &bernoulliCache
+	cache.Lock()
+	defer cache.Unlock()
+	for m := len(cache.b); m <= n; m++ {
+		cache.a = append(cache.a, big.NewRat(1, int64(m+1)))
+		a := cache.a
+		for j := m; j >= 1; j-- {
+			a[j-1].Sub(a[j-1], a[j])
+			a[j-1].Mul(a[j-1], big.NewRat(int64(j), 1))
+		}
+		cache.b = append(cache.b, new(big.Rat).Set(a[0]))
+	}
+	return cache.b[n]
+}
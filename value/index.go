@@ -0,0 +1,174 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+// IndexAssign returns a copy of A in which the elements selected by
+// the index B, as in A[B], are replaced by the corresponding elements
+// of V. If V is a scalar, it replaces all the selected elements.
+// A is not modified, since its value may be shared by other variables.
+func IndexAssign(c Context, A, B, V Value) Value {
+	var shape, data Vector
+	switch A := A.(type) {
+	case Vector:
+		shape, data = Vector{Int(len(A))}, A
+	case Matrix:
+		shape, data = A.shape, A.data
+	default:
+		Errorf("cannot index %s", A)
+	}
+	indexes := indexVector(B)
+	elemSize := size(shape[1:])
+	_, values := shapeAndData(V)
+	if len(values) != 1 && len(values) != len(indexes)*elemSize {
+		Errorf("length mismatch in indexed assignment: %d elements for %d", len(values), len(indexes)*elemSize)
+	}
+	n := make(Vector, len(data))
+	copy(n, data)
+	origin := Int(c.Config().Origin())
+	for i, b := range indexes {
+		x, ok := b.(Int)
+		if !ok {
+			Errorf("index must be integer")
+		}
+		x -= origin
+		if x < 0 || shape[0].(Int) <= x {
+			if _, ok := A.(Vector); ok {
+				Errorf("index %d out of range", x+origin)
+			}
+			Errorf("index %d out of range (shape %s)", x+origin, shape)
+		}
+		for j := 0; j < elemSize; j++ {
+			k := 0
+			if len(values) > 1 {
+				k = i*elemSize + j
+			}
+			n[int(x)*elemSize+j] = values[k].Inner()
+		}
+	}
+	if _, ok := A.(Vector); ok {
+		return n
+	}
+	return NewMatrix(shape, n)
+}
+
+// indexVector returns the elements of the index B as a vector.
+func indexVector(B Value) Vector {
+	switch B := B.(type) {
+	case Vector:
+		return B
+	case Matrix:
+		if len(B.shape) != 1 {
+			Errorf("bad index rank %d", len(B.shape))
+		}
+		return B.data
+	}
+	return Vector{B}
+}
+
+// Index returns the elements of A selected by the indexes, one for each
+// axis of A, as in A[i; j]. A nil index selects all the elements along its
+// axis. Each index must be a scalar or a vector. The result has an axis,
+// as long as the index, for each vector index, so a scalar index removes
+// its axis from the result.
+func Index(c Context, A Value, indexes []Value) Value {
+	shape, data := indexedShapeAndData(A, indexes)
+	newShape, offsets := selection(c, shape, indexes)
+	values := make(Vector, len(offsets))
+	for i, off := range offsets {
+		values[i] = data[off]
+	}
+	switch len(newShape) {
+	case 0:
+		return values[0]
+	case 1:
+		return values
+	}
+	return NewMatrix(newShape, values)
+}
+
+// IndexAssignAxes is like IndexAssign but, as in Index, takes one index
+// for each axis of A, as in A[i; j] = V.
+func IndexAssignAxes(c Context, A Value, indexes []Value, V Value) Value {
+	shape, data := indexedShapeAndData(A, indexes)
+	_, offsets := selection(c, shape, indexes)
+	_, values := shapeAndData(V)
+	if len(values) != 1 && len(values) != len(offsets) {
+		Errorf("length mismatch in indexed assignment: %d elements for %d", len(values), len(offsets))
+	}
+	n := make(Vector, len(data))
+	copy(n, data)
+	for i, off := range offsets {
+		k := 0
+		if len(values) > 1 {
+			k = i
+		}
+		n[off] = values[k].Inner()
+	}
+	if _, ok := A.(Vector); ok {
+		return n
+	}
+	return NewMatrix(shape, n)
+}
+
+// indexedShapeAndData returns the shape and data of A, which must have
+// one axis for each index.
+func indexedShapeAndData(A Value, indexes []Value) (Vector, Vector) {
+	shape, data := shapeAndData(A)
+	if len(shape) == 0 {
+		Errorf("cannot index %s", A)
+	}
+	if len(indexes) != len(shape) {
+		Errorf("rank mismatch: %d indexes for rank %d", len(indexes), len(shape))
+	}
+	return shape, data
+}
+
+// selection returns the shape of the result of indexing an array of the
+// given shape by the indexes, and the offsets in the array's data of the
+// selected elements, in order.
+func selection(c Context, shape Vector, indexes []Value) (Vector, []int) {
+	origin := Int(c.Config().Origin())
+	var newShape Vector
+	positions := make([][]int, len(indexes))
+	for axis, index := range indexes {
+		dim := shape[axis].(Int)
+		if index == nil {
+			positions[axis] = make([]int, dim)
+			for i := range positions[axis] {
+				positions[axis][i] = i
+			}
+			newShape = append(newShape, dim)
+			continue
+		}
+		elems := indexVector(index)
+		if _, ok := index.(Vector); ok {
+			newShape = append(newShape, Int(len(elems)))
+		}
+		for _, b := range elems {
+			x, ok := b.(Int)
+			if !ok {
+				Errorf("index must be integer")
+			}
+			x -= origin
+			if x < 0 || dim <= x {
+				Errorf("index %d out of range (shape %s)", x+origin, shape)
+			}
+			positions[axis] = append(positions[axis], int(x))
+		}
+	}
+	// Compute the offsets, the last axis varying fastest.
+	offsets := []int{0}
+	for axis, pos := range positions {
+		stride := size(shape[axis+1:])
+		next := make([]int, 0, len(offsets)*len(pos))
+		for _, off := range offsets {
+			for _, p := range pos {
+				next = append(next, off+p*stride)
+			}
+		}
+		offsets = next
+	}
+	return newShape, offsets
+}
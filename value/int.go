@@ -144,6 +144,10 @@ func (i Int) toType(conf *config.Config, which valueType) Value {
 		return bigRatInt64(int64(i))
 	case bigFloatType:
 		return bigFloatInt64(conf, int64(i))
+	case complexType:
+		return Complex{i, zero}
+	case boxType:
+		return i
 	case vectorType:
 		return NewVector([]Value{i})
 	case matrixType:
@@ -0,0 +1,593 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "math/big"
+
+// Linear algebra: matrix inverse and division, determinants, rank,
+// and LU and QR decomposition.
+// Systems of equations are solved by Gauss-Jordan elimination, exactly
+// using big.Rats unless an element is floating-point, in which case the
+// elimination is done in big.Floats with partial pivoting.
+// A system with more equations than unknowns is solved in the least
+// squares sense using the normal equations.
+
+// inverse returns the inverse of v, as in inv v. A scalar is inverted
+// by division, a vector is treated as a matrix of one column, and the
+// shape of the result is the reverse of the shape of v.
+func inverse(c Context, v Value) Value {
+	shape, data := shapeAndData(v)
+	if len(shape) == 0 {
+		return c.EvalUnary("/", v)
+	}
+	a := rows(c, "inv", shape, data)
+	// The inverse solves a x = I, where I is the identity.
+	m := len(a)
+	id := make([][]Value, m)
+	for i := range id {
+		id[i] = make([]Value, m)
+		for j := range id[i] {
+			id[i][j] = zero
+		}
+		id[i][i] = one
+	}
+	x := solve(c, "inv", a, id)
+	newShape := make(Vector, len(shape))
+	for i := range shape {
+		newShape[i] = shape[len(shape)-1-i]
+	}
+	return fromRows(newShape, x)
+}
+
+// matrixDivide returns the solution x to the system of linear
+// equations v x = u, as in u mdiv v. A vector is treated as a matrix
+// of one column, and the shape of the result is that of v without its
+// first axis followed by that of u without its first axis.
+func matrixDivide(c Context, u, v Value) Value {
+	ushape, udata := shapeAndData(u)
+	vshape, vdata := shapeAndData(v)
+	if len(ushape) == 0 && len(vshape) == 0 {
+		return c.EvalBinary(u, "/", v)
+	}
+	a := rows(c, "mdiv", vshape, vdata)
+	b := rows(c, "mdiv", ushape, udata)
+	if len(a) != len(b) {
+		Errorf("mdiv: length mismatch: %d equations for %d values", len(a), len(b))
+	}
+	x := solve(c, "mdiv", a, b)
+	newShape := make(Vector, 0, 2)
+	if len(vshape) > 1 {
+		newShape = append(newShape, vshape[1:]...)
+	}
+	if len(ushape) > 1 {
+		newShape = append(newShape, ushape[1:]...)
+	}
+	return fromRows(newShape, x)
+}
+
+// rows returns the elements of the array with the given shape as rows of
+// a matrix. A scalar is a 1x1 matrix and a vector is a single column.
+func rows(c Context, name string, shape, data Vector) [][]Value {
+	nrows, ncols := 1, 1
+	switch len(shape) {
+	case 0:
+	case 1:
+		nrows = int(shape[0].(Int))
+	case 2:
+		nrows, ncols = int(shape[0].(Int)), int(shape[1].(Int))
+	default:
+		Errorf("%s: rank %d matrix", name, len(shape))
+	}
+	if nrows == 0 || ncols == 0 {
+		Errorf("%s: empty matrix", name)
+	}
+	r := make([][]Value, nrows)
+	for i := range r {
+		r[i] = data[i*ncols : (i+1)*ncols]
+	}
+	return r
+}
+
+// fromRows returns the array of the given shape holding the elements of x.
+func fromRows(shape Vector, x [][]Value) Value {
+	data := make(Vector, 0, len(x)*len(x[0]))
+	for _, row := range x {
+		data = append(data, row...)
+	}
+	switch len(shape) {
+	case 0:
+		return data[0]
+	case 1:
+		return data
+	}
+	return NewMatrix(shape, data)
+}
+
+// solve returns x such that a x = b, where a is a matrix with at least as
+// many rows as columns, and b has the same number of rows as a.
+func solve(c Context, name string, a, b [][]Value) [][]Value {
+	if len(a) < len(a[0]) {
+		Errorf("%s: matrix has fewer rows than columns", name)
+	}
+	if len(a) > len(a[0]) {
+		a, b = normalEquations(c, a, b)
+	}
+	if hasFloat(c, name, a, b) {
+		return floatSolve(c, name, floatRows(c, a), floatRows(c, b))
+	}
+	return ratSolve(name, ratRows(c, a), ratRows(c, b))
+}
+
+// hasFloat reports whether any element of the matrices is floating-point.
+// The elements must all be real numbers.
+func hasFloat(c Context, name string, ms ...[][]Value) bool {
+	float := false
+	for _, m := range ms {
+		for _, row := range m {
+			for _, x := range row {
+				switch x.(type) {
+				case Int, BigInt, BigRat:
+				case BigFloat:
+					float = true
+				default:
+					Errorf("%s: non-real element %s", name, x.Sprint(c.Config()))
+				}
+			}
+		}
+	}
+	return float
+}
+
+// normalEquations returns aᵀa and aᵀb, the system whose solution
+// minimizes the squared error of the overdetermined system a x = b.
+func normalEquations(c Context, a, b [][]Value) ([][]Value, [][]Value) {
+	product := func(b [][]Value) [][]Value {
+		p := make([][]Value, len(a[0]))
+		for i := range p {
+			p[i] = make([]Value, len(b[0]))
+			for j := range p[i] {
+				var sum Value = zero
+				for k := range a {
+					sum = c.EvalBinary(sum, "+", c.EvalBinary(a[k][i], "*", b[k][j]))
+				}
+				p[i][j] = sum
+			}
+		}
+		return p
+	}
+	return product(a), product(b)
+}
+
+// ratRows returns the elements of x as big.Rats. Floating-point
+// elements are converted exactly.
+func ratRows(c Context, x [][]Value) [][]*big.Rat {
+	r := make([][]*big.Rat, len(x))
+	for i, row := range x {
+		r[i] = make([]*big.Rat, len(row))
+		for j, v := range row {
+			if f, ok := v.(BigFloat); ok {
+				r[i][j], _ = f.Rat(nil)
+				continue
+			}
+			r[i][j] = new(big.Rat).Set(v.toType(c.Config(), bigRatType).(BigRat).Rat)
+		}
+	}
+	return r
+}
+
+func floatRows(c Context, x [][]Value) [][]*big.Float {
+	r := make([][]*big.Float, len(x))
+	for i, row := range x {
+		r[i] = make([]*big.Float, len(row))
+		for j, v := range row {
+			r[i][j] = newFloat(c).Set(floatSelf(c, v).(BigFloat).Float)
+		}
+	}
+	return r
+}
+
+// ratSolve solves a x = b exactly for square a by Gauss-Jordan elimination.
+// It overwrites a and b.
+func ratSolve(name string, a, b [][]*big.Rat) [][]Value {
+	n := len(a)
+	t := new(big.Rat)
+	for col := 0; col < n; col++ {
+		// Any non-zero pivot will do.
+		p := col
+		for p < n && a[p][col].Sign() == 0 {
+			p++
+		}
+		if p == n {
+			Errorf("%s: matrix is singular", name)
+		}
+		a[col], a[p] = a[p], a[col]
+		b[col], b[p] = b[p], b[col]
+		inv := new(big.Rat).Inv(a[col][col])
+		for j := col; j < n; j++ {
+			a[col][j].Mul(a[col][j], inv)
+		}
+		for _, x := range b[col] {
+			x.Mul(x, inv)
+		}
+		for i := 0; i < n; i++ {
+			if i == col || a[i][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(a[i][col])
+			for j := col; j < n; j++ {
+				a[i][j].Sub(a[i][j], t.Mul(f, a[col][j]))
+			}
+			for j, x := range b[i] {
+				x.Sub(x, t.Mul(f, b[col][j]))
+			}
+		}
+	}
+	x := make([][]Value, n)
+	for i, row := range b {
+		x[i] = make([]Value, len(row))
+		for j, r := range row {
+			x[i][j] = BigRat{r}.shrink()
+		}
+	}
+	return x
+}
+
+// floatSolve solves a x = b for square a by Gauss-Jordan elimination
+// with partial pivoting. It overwrites a and b.
+func floatSolve(c Context, name string, a, b [][]*big.Float) [][]Value {
+	n := len(a)
+	t := newFloat(c)
+	mag := newFloat(c)
+	for col := 0; col < n; col++ {
+		// Choose the pivot of largest magnitude.
+		p := col
+		for i := col + 1; i < n; i++ {
+			if mag.Abs(a[i][col]).Cmp(t.Abs(a[p][col])) > 0 {
+				p = i
+			}
+		}
+		if a[p][col].Sign() == 0 {
+			Errorf("%s: matrix is singular", name)
+		}
+		a[col], a[p] = a[p], a[col]
+		b[col], b[p] = b[p], b[col]
+		pivot := newFloat(c).Set(a[col][col])
+		for j := col; j < n; j++ {
+			a[col][j].Quo(a[col][j], pivot)
+		}
+		for _, x := range b[col] {
+			x.Quo(x, pivot)
+		}
+		for i := 0; i < n; i++ {
+			if i == col || a[i][col].Sign() == 0 {
+				continue
+			}
+			f := newFloat(c).Set(a[i][col])
+			for j := col; j < n; j++ {
+				a[i][j].Sub(a[i][j], t.Mul(f, a[col][j]))
+			}
+			for j, x := range b[i] {
+				x.Sub(x, t.Mul(f, b[col][j]))
+			}
+		}
+	}
+	x := make([][]Value, n)
+	for i, row := range b {
+		x[i] = make([]Value, len(row))
+		for j, f := range row {
+			x[i][j] = BigFloat{f}.shrink()
+		}
+	}
+	return x
+}
+
+// square returns the rows of the square matrix v.
+func square(c Context, name string, v Value) [][]Value {
+	shape, data := shapeAndData(v)
+	if len(shape) != 2 || shape[0] != shape[1] {
+		Errorf("%s: matrix is not square", name)
+	}
+	return rows(c, name, shape, data)
+}
+
+// determinant returns the determinant of the square matrix v, as in det v.
+// It is computed from the LU decomposition, exactly unless an element
+// of v is floating-point. The determinant of a scalar is the scalar.
+func determinant(c Context, v Value) Value {
+	if rank(v) == 0 {
+		return v
+	}
+	a := square(c, "det", v)
+	if hasFloat(c, "det", a) {
+		_, u, _, sign := floatLU(c, floatRows(c, a))
+		d := newFloat(c).SetInt64(int64(sign))
+		for i := range u {
+			d.Mul(d, u[i][i])
+		}
+		return BigFloat{d}.shrink()
+	}
+	_, u, _, sign := ratLU(ratRows(c, a))
+	d := big.NewRat(int64(sign), 1)
+	for i := range u {
+		d.Mul(d, u[i][i])
+	}
+	return BigRat{d}.shrink()
+}
+
+// matrixRank returns the rank of the matrix v, as in rank v: the number of
+// linearly independent rows. It is computed exactly, even for
+// floating-point elements, by reducing v to row echelon form.
+func matrixRank(c Context, v Value) Value {
+	shape, data := shapeAndData(v)
+	if len(shape) == 0 {
+		return toInt(toBool(v))
+	}
+	a := rows(c, "rank", shape, data)
+	hasFloat(c, "rank", a) // Check that the elements are real.
+	r := ratRows(c, a)
+	rank := 0
+	t := new(big.Rat)
+	for col := 0; col < len(r[0]) && rank < len(r); col++ {
+		p := rank
+		for p < len(r) && r[p][col].Sign() == 0 {
+			p++
+		}
+		if p == len(r) {
+			continue
+		}
+		r[rank], r[p] = r[p], r[rank]
+		for i := rank + 1; i < len(r); i++ {
+			if r[i][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Quo(r[i][col], r[rank][col])
+			for j := col; j < len(r[i]); j++ {
+				r[i][j].Sub(r[i][j], t.Mul(f, r[rank][j]))
+			}
+		}
+		rank++
+	}
+	return Int(rank)
+}
+
+// luDecomposition returns the LU decomposition of the square matrix v,
+// as in lu v: a vector of three boxed matrices L, U and P such that
+// L is unit lower triangular, U is upper triangular, P is a permutation
+// matrix, and P +.* v is L +.* U. It is exact unless an element of v is
+// floating-point.
+func luDecomposition(c Context, v Value) Value {
+	a := square(c, "lu", v)
+	n := len(a)
+	var l, u []Value
+	var perm []int
+	if hasFloat(c, "lu", a) {
+		fl, fu, fperm, _ := floatLU(c, floatRows(c, a))
+		l, u, perm = floatData(fl), floatData(fu), fperm
+	} else {
+		rl, ru, rperm, _ := ratLU(ratRows(c, a))
+		l, u, perm = ratData(rl), ratData(ru), rperm
+	}
+	p := make(Vector, n*n)
+	for i := range p {
+		p[i] = zero
+	}
+	for i, j := range perm {
+		p[i*n+j] = one
+	}
+	shape := Vector{Int(n), Int(n)}
+	return NewVector([]Value{
+		NewBox(NewMatrix(shape, l)),
+		NewBox(NewMatrix(shape, u)),
+		NewBox(NewMatrix(shape, p)),
+	})
+}
+
+// ratLU computes the LU decomposition of the square matrix a, which
+// it overwrites with U. Row i of the permuted matrix is row perm[i] of a.
+// The sign is -1 if the permutation is odd, 1 otherwise.
+func ratLU(a [][]*big.Rat) (l, u [][]*big.Rat, perm []int, sign int) {
+	n := len(a)
+	l = make([][]*big.Rat, n)
+	perm = make([]int, n)
+	for i := range l {
+		l[i] = make([]*big.Rat, n)
+		for j := range l[i] {
+			l[i][j] = new(big.Rat)
+		}
+		l[i][i].SetInt64(1)
+		perm[i] = i
+	}
+	sign = 1
+	t := new(big.Rat)
+	for col := 0; col < n; col++ {
+		// Any non-zero pivot will do. If there is none,
+		// the column is already eliminated.
+		p := col
+		for p < n && a[p][col].Sign() == 0 {
+			p++
+		}
+		if p == n {
+			continue
+		}
+		if p != col {
+			a[col], a[p] = a[p], a[col]
+			perm[col], perm[p] = perm[p], perm[col]
+			for j := 0; j < col; j++ {
+				l[col][j], l[p][j] = l[p][j], l[col][j]
+			}
+			sign = -sign
+		}
+		for i := col + 1; i < n; i++ {
+			if a[i][col].Sign() == 0 {
+				continue
+			}
+			f := l[i][col].Quo(a[i][col], a[col][col])
+			for j := col; j < n; j++ {
+				a[i][j].Sub(a[i][j], t.Mul(f, a[col][j]))
+			}
+		}
+	}
+	return l, a, perm, sign
+}
+
+// floatLU is like ratLU but for floating-point elements,
+// and it uses partial pivoting.
+func floatLU(c Context, a [][]*big.Float) (l, u [][]*big.Float, perm []int, sign int) {
+	n := len(a)
+	l = make([][]*big.Float, n)
+	perm = make([]int, n)
+	for i := range l {
+		l[i] = make([]*big.Float, n)
+		for j := range l[i] {
+			l[i][j] = newFloat(c)
+		}
+		l[i][i].SetInt64(1)
+		perm[i] = i
+	}
+	sign = 1
+	t := newFloat(c)
+	mag := newFloat(c)
+	for col := 0; col < n; col++ {
+		// Choose the pivot of largest magnitude.
+		p := col
+		for i := col + 1; i < n; i++ {
+			if mag.Abs(a[i][col]).Cmp(t.Abs(a[p][col])) > 0 {
+				p = i
+			}
+		}
+		if a[p][col].Sign() == 0 {
+			continue
+		}
+		if p != col {
+			a[col], a[p] = a[p], a[col]
+			perm[col], perm[p] = perm[p], perm[col]
+			for j := 0; j < col; j++ {
+				l[col][j], l[p][j] = l[p][j], l[col][j]
+			}
+			sign = -sign
+		}
+		for i := col + 1; i < n; i++ {
+			if a[i][col].Sign() == 0 {
+				continue
+			}
+			f := l[i][col].Quo(a[i][col], a[col][col])
+			for j := col; j < n; j++ {
+				a[i][j].Sub(a[i][j], t.Mul(f, a[col][j]))
+			}
+		}
+	}
+	return l, a, perm, sign
+}
+
+// qrDecomposition returns the QR decomposition of the matrix v, as in
+// qr v: a vector of two boxed matrices Q and R such that the columns of Q
+// are orthonormal, R is upper triangular, and v is Q +.* R. The matrix
+// must have at least as many rows as columns and its columns must be
+// linearly independent. The decomposition, by modified Gram-Schmidt
+// orthogonalization, is always done in floating point.
+func qrDecomposition(c Context, v Value) Value {
+	shape, data := shapeAndData(v)
+	if len(shape) != 2 {
+		Errorf("qr: argument must be a matrix")
+	}
+	a := rows(c, "qr", shape, data)
+	hasFloat(c, "qr", a) // Check that the elements are real.
+	m, n := len(a), len(a[0])
+	if m < n {
+		Errorf("qr: matrix has fewer rows than columns")
+	}
+	if matrixRank(c, v) != Int(n) {
+		Errorf("qr: columns are linearly dependent")
+	}
+	q := floatRows(c, a) // Orthogonalized in place, column by column.
+	r := make([][]*big.Float, n)
+	for i := range r {
+		r[i] = make([]*big.Float, n)
+		for j := range r[i] {
+			r[i][j] = newFloat(c)
+		}
+	}
+	t := newFloat(c)
+	for k := 0; k < n; k++ {
+		norm := newFloat(c)
+		for i := 0; i < m; i++ {
+			norm.Add(norm, t.Mul(q[i][k], q[i][k]))
+		}
+		if norm.Sign() == 0 {
+			Errorf("qr: columns are linearly dependent")
+		}
+		norm = floatSqrt(c, norm)
+		r[k][k] = norm
+		for i := 0; i < m; i++ {
+			q[i][k].Quo(q[i][k], norm)
+		}
+		for j := k + 1; j < n; j++ {
+			dot := r[k][j]
+			for i := 0; i < m; i++ {
+				dot.Add(dot, t.Mul(q[i][k], q[i][j]))
+			}
+			for i := 0; i < m; i++ {
+				q[i][j].Sub(q[i][j], t.Mul(dot, q[i][k]))
+			}
+		}
+	}
+	return NewVector([]Value{
+		NewBox(NewMatrix(Vector{Int(m), Int(n)}, floatData(q))),
+		NewBox(NewMatrix(Vector{Int(n), Int(n)}, floatData(r))),
+	})
+}
+
+func ratData(x [][]*big.Rat) Vector {
+	var data Vector
+	for _, row := range x {
+		for _, r := range row {
+			data = append(data, BigRat{r}.shrink())
+		}
+	}
+	return data
+}
+
+func floatData(x [][]*big.Float) Vector {
+	var data Vector
+	for _, row := range x {
+		for _, f := range row {
+			data = append(data, BigFloat{f}.shrink())
+		}
+	}
+	return data
+}
+
+// trace returns the sum of the diagonal elements of the square matrix v,
+// as in trace v. The trace of a scalar is the scalar.
+func trace(c Context, v Value) Value {
+	if rank(v) == 0 {
+		return v
+	}
+	a := square(c, "trace", v)
+	var sum Value = zero
+	for i := range a {
+		sum = c.EvalBinary(sum, "+", a[i][i])
+	}
+	return sum
+}
+
+// identity returns the n by n identity matrix, as in identity n.
+func identity(c Context, v Value) Value {
+	n, ok := v.(Int)
+	if !ok || n < 0 {
+		Errorf("identity: size must be a non-negative small integer")
+	}
+	if n*n > maxInt {
+		Errorf("identity has too many elements")
+	}
+	data := make(Vector, n*n)
+	for i := range data {
+		data[i] = zero
+	}
+	for i := 0; i < int(n); i++ {
+		data[i*int(n)+i] = one
+	}
+	return NewMatrix(Vector{n, n}, data)
+}
@@ -7,6 +7,10 @@ package value
 import "math/big"
 
 func logn(c Context, v Value) Value {
+	if isNegative(c, v) {
+		// log -x is log x + πi.
+		return newComplex(evalFloatFunc(c, c.EvalUnary("-", v), floatLog), BigFloat{newFloat(c).Set(floatPi)})
+	}
 	return evalFloatFunc(c, v, floatLog)
 }
 
@@ -19,6 +23,10 @@ func floatLog(c Context, x *big.Float) *big.Float {
 	if x.Sign() <= 0 {
 		Errorf("log of non-positive value")
 	}
+	if x.Cmp(floatOne) == 0 {
+		// The series leaves a tiny residue; log 1 is exactly zero.
+		return newFloat(c)
+	}
 	// The series wants x < 1, and log 1/x == -log x, so exploit that.
 	invert := false
 	x = newFloat(c).Set(x) // Don't modify argument!
@@ -91,12 +91,11 @@ func (m Matrix) Sprint(conf *config.Config) string {
 			}
 			break
 		}
-		// We print the elements into one big string,
-		// slice that, and then format so they line up.
+		// We print the elements individually and then
+		// format them so they line up.
 		// Will need some rethinking when decimal points
 		// can appear.
-		// Vector.String does what we want for the first part.
-		strs := strings.Split(m.data.makeString(conf, true), " ")
+		strs := m.data.elemStrings(conf)
 		wid := 1
 		for _, s := range strs {
 			if wid < len(s) {
@@ -121,7 +120,7 @@ func (m Matrix) Sprint(conf *config.Config) string {
 		}
 		// As for 2d: print the vector elements, compute the
 		// global width, and use that to print each 2d submatrix.
-		strs := strings.Split(m.data.Sprint(conf), " ")
+		strs := m.data.elemStrings(conf)
 		wid := 1
 		for _, s := range strs {
 			if wid < len(s) {
@@ -0,0 +1,171 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Number-theoretic functions of integers.
+
+// bigIntOf returns the integer v, an Int or BigInt, as a new big.Int.
+func bigIntOf(v Value) *big.Int {
+	switch v := v.(type) {
+	case Int:
+		return big.NewInt(int64(v))
+	case BigInt:
+		return new(big.Int).Set(v.Int)
+	}
+	Errorf("internal error: bigIntOf of non-integer %s", v)
+	panic("not reached")
+}
+
+// gcd returns the greatest common divisor of u and v, which is never negative.
+func gcd(c Context, u, v Value) Value {
+	return BigInt{new(big.Int).GCD(nil, nil, bigIntOf(u), bigIntOf(v))}.shrink()
+}
+
+// lcm returns the least common multiple of u and v, which is never negative.
+func lcm(c Context, u, v Value) Value {
+	a, b := bigIntOf(u), bigIntOf(v)
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return zero
+	}
+	mustFit(c.Config(), int64(a.BitLen()+b.BitLen()))
+	g := new(big.Int).GCD(nil, nil, a, b)
+	a.Quo(a, g)
+	a.Mul(a, b)
+	return BigInt{a.Abs(a)}.shrink()
+}
+
+// isPrime returns 1 if v is prime, 0 otherwise. The test is probabilistic,
+// but the chance of a composite number passing is negligible.
+func isPrime(c Context, v Value) Value {
+	return toInt(bigIntOf(v).ProbablyPrime(20))
+}
+
+// factors returns the prime factors of v, in increasing order and
+// repeated according to their multiplicity.
+func factors(c Context, v Value) Value {
+	n := bigIntOf(v)
+	if n.Sign() <= 0 {
+		Errorf("factors: argument must be positive")
+	}
+	var f []*big.Int
+	// Small primes first, by trial division.
+	var q, r big.Int
+	for p := int64(2); p < 1000 && n.Cmp(big.NewInt(p*p)) >= 0; p++ {
+		bp := big.NewInt(p)
+		for {
+			q.QuoRem(n, bp, &r)
+			if r.Sign() != 0 {
+				break
+			}
+			f = append(f, bp)
+			n.Set(&q)
+		}
+	}
+	if n.Cmp(big.NewInt(1)) > 0 {
+		f = append(f, bigFactors(n)...)
+	}
+	sort.Slice(f, func(i, j int) bool { return f[i].Cmp(f[j]) < 0 })
+	x := make([]Value, len(f))
+	for i, p := range f {
+		x[i] = BigInt{p}.shrink()
+	}
+	return NewVector(x)
+}
+
+// bigFactors returns the prime factors, in no particular order, of n,
+// which is greater than one and has no small factors.
+func bigFactors(n *big.Int) []*big.Int {
+	if n.ProbablyPrime(20) {
+		return []*big.Int{n}
+	}
+	d := pollardRho(n)
+	return append(bigFactors(d), bigFactors(new(big.Int).Quo(n, d))...)
+}
+
+// pollardRho returns a non-trivial divisor of the composite n, using
+// Brent's variant of Pollard's rho algorithm.
+func pollardRho(n *big.Int) *big.Int {
+	one := big.NewInt(1)
+	for inc := int64(1); ; inc++ {
+		// Iterate x = x²+inc mod n. A cycle in the sequence modulo
+		// a factor p shows up as a gcd of the difference with n.
+		x, y := big.NewInt(2), big.NewInt(2)
+		d := big.NewInt(1)
+		diff := new(big.Int)
+		for power, lam := 1, 1; d.Cmp(one) == 0; lam++ {
+			if power == lam {
+				x.Set(y)
+				power *= 2
+				lam = 0
+			}
+			y.Mul(y, y)
+			y.Add(y, big.NewInt(inc))
+			y.Mod(y, n)
+			diff.Sub(x, y)
+			d.GCD(nil, nil, diff.Abs(diff), n)
+		}
+		if d.Cmp(n) != 0 {
+			return d
+		}
+		// The cycle closed modulo n itself; try another sequence.
+	}
+}
+
+// modPow returns u[1]**u[2] modulo v. A negative exponent means
+// a power of the modular inverse.
+func modPow(c Context, u, v Value) Value {
+	x, ok := u.(Vector)
+	if !ok || len(x) != 2 {
+		Errorf("modpow: left operand must be base and exponent")
+	}
+	if m, ok := v.(Vector); ok && len(m) == 1 {
+		v = m[0]
+	}
+	m := modulus(c, "modpow", v)
+	base, exp := bigIntOf(integer(c, "modpow", x[0])), bigIntOf(integer(c, "modpow", x[1]))
+	if exp.Sign() < 0 {
+		base = modInverse(base, m)
+		exp.Neg(exp)
+	}
+	return BigInt{new(big.Int).Exp(base, exp, m)}.shrink()
+}
+
+// modInv returns the inverse of u modulo v.
+func modInv(c Context, u, v Value) Value {
+	return BigInt{modInverse(bigIntOf(u), modulus(c, "modinv", v))}.shrink()
+}
+
+// modInverse returns the inverse of a modulo m, which must exist.
+func modInverse(a, m *big.Int) *big.Int {
+	z := new(big.Int).ModInverse(a, m)
+	if z == nil {
+		Errorf("%s has no inverse modulo %s", a, m)
+	}
+	return z
+}
+
+// modulus returns the modulus v, which must be a positive integer, as a big.Int.
+func modulus(c Context, name string, v Value) *big.Int {
+	m := bigIntOf(integer(c, name, v))
+	if m.Sign() <= 0 {
+		Errorf("%s: modulus must be positive", name)
+	}
+	return m
+}
+
+// integer returns v, after checking that it is an Int or BigInt.
+func integer(c Context, name string, v Value) Value {
+	switch v.(type) {
+	case Int, BigInt:
+		return v
+	}
+	Errorf("%s: %s is not an integer", name, v.Sprint(c.Config()))
+	panic("not reached")
+}
@@ -104,3 +104,20 @@ func integerPower(c Context, x *big.Float, exp int64) *big.Float {
 	}
 	return z
 }
+
+// floatExp computes e**x. Unlike exponential, it is accurate for x of
+// any size or sign, because it uses the series only for the fractional
+// part of |x|.
+func floatExp(c Context, x *big.Float) *big.Float {
+	a := newFloat(c).Abs(x)
+	if a.MantExp(nil) > 31 {
+		Errorf("exponential of %s is too large", BigFloat{x}.Sprint(c.Config()))
+	}
+	i, _ := a.Int64()
+	z := integerPower(c, floatE, i)
+	z.Mul(z, exponential(c.Config(), a.Sub(a, newFloat(c).SetInt64(i))))
+	if x.Sign() < 0 {
+		z.Quo(floatOne, z)
+	}
+	return z
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "math/big"
+
+// All random values are drawn from the configured source, so )seed
+// makes them reproducible.
+
+// roll returns one integer selected randomly from the first v integers,
+// or, if v is zero, a floating-point number selected uniformly from [0, 1).
+func roll(c Context, v Value) Value {
+	i := int64(v.(Int))
+	switch {
+	case i == 0:
+		return BigFloat{uniform(c)}
+	case i < 0:
+		Errorf("illegal roll value %v", v)
+	}
+	return Int(c.Config().Origin()) + Int(c.Config().Random().Int63n(i))
+}
+
+// deal returns u distinct integers selected randomly from the first v integers.
+func deal(c Context, u, v Value) Value {
+	n, max := u.(Int), v.(Int)
+	if n < 0 || max < 0 || n > max {
+		Errorf("illegal deal %d ? %d", n, max)
+	}
+	// A Fisher-Yates shuffle of the first n elements of iota max,
+	// storing only the elements that have moved.
+	moved := make(map[Int]Int)
+	at := func(i Int) Int {
+		if x, ok := moved[i]; ok {
+			return x
+		}
+		return i
+	}
+	origin := Int(c.Config().Origin())
+	r := c.Config().Random()
+	x := make([]Value, n)
+	for i := Int(0); i < n; i++ {
+		j := i + Int(r.Int63n(int64(max-i)))
+		x[i] = at(j) + origin
+		moved[j] = at(i)
+	}
+	return NewVector(x)
+}
+
+// bigDeal is deal for v too large for an Int. The chance that an
+// integer repeats is negligible, but if one does we choose again.
+func bigDeal(c Context, u, v Value) Value {
+	n, ok := u.(BigInt).shrink().(Int)
+	max := v.(BigInt)
+	if !ok || n < 0 || max.Sign() < 0 {
+		Errorf("illegal deal %s ? %s", u.Sprint(c.Config()), v.Sprint(c.Config()))
+	}
+	seen := make(map[string]bool)
+	x := make([]Value, 0, n)
+	for Int(len(x)) < n {
+		i := bigIntRand(c, new(big.Int), max.Int)
+		if key := i.String(); !seen[key] {
+			seen[key] = true
+			x = append(x, BigInt{i}.shrink())
+		}
+	}
+	return NewVector(x)
+}
+
+// uniform returns a number selected uniformly from [0, 1), with
+// every bit of the mantissa random at the configured precision.
+func uniform(c Context) *big.Float {
+	prec := c.Config().FloatPrec()
+	n := new(big.Int).Lsh(big.NewInt(1), prec)
+	n.Rand(c.Config().Random(), n)
+	z := newFloat(c).SetInt(n)
+	return z.SetMantExp(z, -int(prec))
+}
+
+// normal returns a number selected from the normal distribution
+// with mean 0 and standard deviation v.
+func normal(c Context, v Value) Value {
+	if isNegative(c, v) {
+		Errorf("randn: negative standard deviation")
+	}
+	// The Box-Muller transform: if u1 and u2 are uniform on (0, 1],
+	// sqrt(-2 log u1) cos 2πu2 is normally distributed.
+	u1 := uniform(c)
+	u1.Sub(floatOne, u1)
+	u2 := uniform(c)
+	u2.Mul(u2, floatTwo)
+	u2.Mul(u2, floatPi)
+	z := floatLog(c, u1)
+	z.Mul(z, newFloat(c).SetInt64(-2))
+	z = floatSqrt(c, z)
+	z.Mul(z, floatCos(c, u2))
+	return c.EvalBinary(BigFloat{z}.shrink(), "*", v)
+}
+
+// exponentialRand returns a number selected from the exponential
+// distribution with mean v.
+func exponentialRand(c Context, v Value) Value {
+	if isNegative(c, v) {
+		Errorf("randexp: negative mean")
+	}
+	// If u is uniform on (0, 1], -log u is exponentially distributed.
+	u := uniform(c)
+	z := floatLog(c, u.Sub(floatOne, u))
+	return c.EvalBinary(BigFloat{z.Neg(z)}.shrink(), "*", v)
+}
@@ -0,0 +1,245 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import "math/big"
+
+func sinh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatSinh)
+}
+
+func cosh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatCosh)
+}
+
+func tanh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatTanh)
+}
+
+func asinh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatAsinh)
+}
+
+func acosh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatAcosh)
+}
+
+func atanh(c Context, v Value) Value {
+	return evalFloatFunc(c, v, floatAtanh)
+}
+
+// floatSinh computes sinh(x). For small x, (e**x - e**-x)/2 loses
+// precision to cancellation, so we use the Taylor series instead.
+func floatSinh(c Context, x *big.Float) *big.Float {
+	if x.MantExp(nil) > 0 { // |x| >= 1.
+		ex := floatExp(c, x)
+		z := newFloat(c).Quo(floatOne, ex)
+		z.Sub(ex, z)
+		return z.Quo(z, floatTwo)
+	}
+	// The Taylor series for sinh(x) is x + x³/3! + x⁵/5! ...
+	x2 := newFloat(c).Mul(x, x)
+	term := newFloat(c).Set(x)
+	z := newFloat(c).Set(x)
+	n := newFloat(c)
+	for loop := newLoop(c.Config(), "sinh", x, 4); ; {
+		// Advance the term by x²/((2k)(2k+1)).
+		term.Mul(term, x2)
+		term.Quo(term, n.SetUint64(2*(loop.i+1)*(2*(loop.i+1)+1)))
+		z.Add(z, term)
+		if loop.done(z) {
+			break
+		}
+	}
+	return z
+}
+
+// floatCosh computes cosh(x) as (e**x + e**-x)/2.
+func floatCosh(c Context, x *big.Float) *big.Float {
+	ex := floatExp(c, x)
+	z := newFloat(c).Quo(floatOne, ex)
+	z.Add(ex, z)
+	return z.Quo(z, floatTwo)
+}
+
+// floatTanh computes tanh(x) as sinh(x)/cosh(x).
+func floatTanh(c Context, x *big.Float) *big.Float {
+	// For large x, tanh(x) is ±1 to within the precision, and
+	// the exponentials might not be representable.
+	if x.MantExp(nil) > 30 {
+		return newFloat(c).SetInt64(int64(x.Sign()))
+	}
+	z := floatSinh(c, x)
+	return z.Quo(z, floatCosh(c, x))
+}
+
+// floatAsinh computes asinh(x) as log(x + sqrt(x²+1)), or for small x
+// where that loses precision, as atanh(x/sqrt(x²+1)).
+func floatAsinh(c Context, x *big.Float) *big.Float {
+	if x.Sign() < 0 {
+		z := floatAsinh(c, newFloat(c).Neg(x))
+		return z.Neg(z)
+	}
+	root := newFloat(c).Mul(x, x)
+	root.Add(root, floatOne)
+	root = floatSqrt(c, root)
+	if x.MantExp(nil) < 0 { // |x| < 1/2.
+		return floatAtanh(c, root.Quo(x, root))
+	}
+	return floatLog(c, root.Add(root, x))
+}
+
+// floatAcosh computes acosh(x) as log(x + sqrt(x²-1)).
+func floatAcosh(c Context, x *big.Float) *big.Float {
+	if x.Cmp(floatOne) < 0 {
+		Errorf("acosh of value less than 1")
+	}
+	z := newFloat(c).Mul(x, x)
+	z.Sub(z, floatOne)
+	z = floatSqrt(c, z)
+	return floatLog(c, z.Add(z, x))
+}
+
+// floatAtanh computes atanh(x) as log((1+x)/(1-x))/2, or for small x
+// where that loses precision, by the Taylor series.
+func floatAtanh(c Context, x *big.Float) *big.Float {
+	a := newFloat(c).Abs(x)
+	switch a.Cmp(floatOne) {
+	case 0:
+		Errorf("hyperbolic arctangent is infinite")
+	case 1:
+		Errorf("atanh of value greater than 1 in magnitude")
+	}
+	if x.MantExp(nil) >= 0 { // |x| >= 1/2.
+		z := newFloat(c).Add(floatOne, x)
+		z.Quo(z, a.Sub(floatOne, x))
+		z = floatLog(c, z)
+		return z.Quo(z, floatTwo)
+	}
+	// The Taylor series for atanh(x) is x + x³/3 + x⁵/5 ...
+	x2 := newFloat(c).Mul(x, x)
+	xN := newFloat(c).Set(x)
+	term := newFloat(c)
+	z := newFloat(c).Set(x)
+	n := newFloat(c)
+	for loop := newLoop(c.Config(), "atanh", x, 4); ; {
+		xN.Mul(xN, x2)
+		term.Quo(xN, n.SetUint64(2*(loop.i+1)+1))
+		z.Add(z, term)
+		if loop.done(z) {
+			break
+		}
+	}
+	return z
+}
+
+// circle evaluates the APL circle function u○v, which selects, by the
+// integer u, a trigonometric, hyperbolic, or complex function of v:
+//
+//	 0 sqrt 1-v**2
+//	 1 sin v         -1 asin v
+//	 2 cos v         -2 acos v
+//	 3 tan v         -3 atan v
+//	 4 sqrt 1+v**2   -4 sqrt -1+v**2
+//	 5 sinh v        -5 asinh v
+//	 6 cosh v        -6 acosh v
+//	 7 tanh v        -7 atanh v
+//	 8 sqrt -1-v**2  -8 -sqrt -1-v**2
+//	 9 real part     -9 v
+//	10 abs v        -10 conjugate of v
+//	11 imaginary    -11 v times 0j1
+//	12 phase        -12 e**v times 0j1
+func circle(c Context, u, v Value) Value {
+	n, ok := circleFunction(u)
+	if !ok {
+		Errorf("circle: bad function number %s", u.Sprint(c.Config()))
+	}
+	square := func() Value { return c.EvalBinary(v, "*", v) }
+	switch n {
+	case 0:
+		return c.EvalUnary("sqrt", c.EvalBinary(one, "-", square()))
+	case 1:
+		return c.EvalUnary("sin", v)
+	case -1:
+		return c.EvalUnary("asin", v)
+	case 2:
+		return c.EvalUnary("cos", v)
+	case -2:
+		return c.EvalUnary("acos", v)
+	case 3:
+		return c.EvalUnary("tan", v)
+	case -3:
+		return c.EvalUnary("atan", v)
+	case 4:
+		return c.EvalUnary("sqrt", c.EvalBinary(one, "+", square()))
+	case -4:
+		return c.EvalUnary("sqrt", c.EvalBinary(minusOne, "+", square()))
+	case 5:
+		return c.EvalUnary("sinh", v)
+	case -5:
+		return c.EvalUnary("asinh", v)
+	case 6:
+		return c.EvalUnary("cosh", v)
+	case -6:
+		return c.EvalUnary("acosh", v)
+	case 7:
+		return c.EvalUnary("tanh", v)
+	case -7:
+		return c.EvalUnary("atanh", v)
+	case 8:
+		return c.EvalUnary("sqrt", c.EvalBinary(minusOne, "-", square()))
+	case -8:
+		return c.EvalUnary("-", c.EvalUnary("sqrt", c.EvalBinary(minusOne, "-", square())))
+	case 9:
+		if z, ok := v.(Complex); ok {
+			return z.real
+		}
+		return v
+	case -9:
+		return v
+	case 10:
+		return c.EvalUnary("abs", v)
+	case -10:
+		if z, ok := v.(Complex); ok {
+			return newComplex(z.real, c.EvalUnary("-", z.imag))
+		}
+		return v
+	case 11:
+		if z, ok := v.(Complex); ok {
+			return z.imag
+		}
+		return zero
+	case -11:
+		return c.EvalBinary(imaginaryOne, "*", v)
+	case 12:
+		if z, ok := v.(Complex); ok {
+			re, im := z.floats(c)
+			return BigFloat{floatAtan2(c, im, re)}.shrink()
+		}
+		if isNegative(c, v) {
+			return BigFloat{newFloat(c).Set(floatPi)}
+		}
+		return zero
+	case -12:
+		return c.EvalUnary("**", c.EvalBinary(imaginaryOne, "*", v))
+	}
+	panic("not reached")
+}
+
+// circleFunction returns the function number u of u○v, which must be
+// a small integer.
+func circleFunction(u Value) (int, bool) {
+	if z, ok := u.(Complex); ok {
+		if toBool(z.imag) {
+			return 0, false
+		}
+		u = z.real
+	}
+	n, ok := bigInteger(u)
+	if !ok || !n.IsInt64() || n.Int64() < -12 || n.Int64() > 12 {
+		return 0, false
+	}
+	return int(n.Int64()), true
+}
@@ -7,9 +7,18 @@ package value
 import "math/big"
 
 func sqrt(c Context, v Value) Value {
+	if isNegative(c, v) {
+		// The square root of a negative number is imaginary.
+		return newComplex(zero, evalFloatFunc(c, c.EvalUnary("-", v), floatSqrt))
+	}
 	return evalFloatFunc(c, v, floatSqrt)
 }
 
+// isNegative reports whether the real scalar v is less than zero.
+func isNegative(c Context, v Value) bool {
+	return toBool(c.EvalBinary(v, "<", zero))
+}
+
 func evalFloatFunc(c Context, v Value, fn func(Context, *big.Float) *big.Float) Value {
 	return BigFloat{(fn(c, floatSelf(c, v).(BigFloat).Float))}.shrink()
 }
@@ -0,0 +1,133 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package value
+
+import (
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatText implements binary text, u text v, which formats the
+// elements of v into characters under control of u, independent of
+// the configured format. The left operand holds pairs of numbers,
+// field width and number of decimal places, one pair for each column
+// of v, or one pair for all columns. A single number is the decimal
+// places, with the width chosen to fit. A width of zero also means to
+// choose the width, leaving a blank before each column. A negative
+// number of decimal places means to use exponential notation with that
+// many significant digits. A value too wide for its field is shown as
+// asterisks. The result is a char vector, or for a matrix v a char
+// matrix with one row for each row of v.
+func formatText(c Context, u, v Value) Value {
+	ushape, udata := shapeAndData(u)
+	if len(ushape) > 1 {
+		Errorf("text: left operand must be a vector")
+	}
+	shape, data := shapeAndData(v)
+	cols := 1
+	if len(shape) > 0 {
+		cols = int(shape[len(shape)-1].(Int))
+	}
+	spec := make([]int, len(udata))
+	for i, x := range udata {
+		n, ok := x.(Int)
+		if !ok {
+			Errorf("text: format must be small integers")
+		}
+		spec[i] = int(n)
+	}
+	switch len(spec) {
+	case 1:
+		spec = []int{0, spec[0]}
+	case 2, 2 * cols:
+	default:
+		Errorf("text: format has %d elements for %d columns", len(spec), cols)
+	}
+	// pair returns the width and decimal places for column j.
+	pair := func(j int) (width, decimals int) {
+		if len(spec) == 2 {
+			return spec[0], spec[1]
+		}
+		return spec[2*j], spec[2*j+1]
+	}
+	strs := make([]string, len(data))
+	for i, x := range data {
+		_, decimals := pair(i % cols)
+		strs[i] = formatElem(c, x, decimals)
+	}
+	widths := make([]int, cols)
+	for j := range widths {
+		widths[j], _ = pair(j)
+		if widths[j] < 0 {
+			Errorf("text: negative width %d", widths[j])
+		}
+		if widths[j] == 0 {
+			for i := j; i < len(strs); i += cols {
+				if n := utf8.RuneCountInString(strs[i]) + 1; n > widths[j] {
+					widths[j] = n
+				}
+			}
+		}
+	}
+	var elems []Value
+	for i, s := range strs {
+		w := widths[i%cols]
+		n := utf8.RuneCountInString(s)
+		if n > w {
+			s, n = strings.Repeat("*", w), w
+		}
+		for ; n < w; n++ {
+			elems = append(elems, Char(' '))
+		}
+		for _, r := range s {
+			elems = append(elems, Char(r))
+		}
+	}
+	if len(shape) <= 1 {
+		return NewVector(elems)
+	}
+	rowWidth := 0
+	for _, w := range widths {
+		rowWidth += w
+	}
+	rshape := make(Vector, len(shape))
+	copy(rshape, shape)
+	rshape[len(rshape)-1] = Int(rowWidth)
+	return NewMatrix(rshape, elems)
+}
+
+// formatElem formats a single element for formatText with the specified
+// number of decimal places, or if decimals is negative, in exponential
+// notation with -decimals significant digits.
+func formatElem(c Context, v Value, decimals int) string {
+	switch v := v.(type) {
+	case Char:
+		return string(v)
+	case Complex:
+		return formatElem(c, v.real, decimals) + "j" + formatElem(c, v.imag, decimals)
+	case Box:
+		Errorf("text: cannot format boxed value")
+	case BigFloat:
+		if decimals < 0 {
+			return v.Text('e', -decimals-1)
+		}
+		return v.Text('f', decimals)
+	}
+	// An exact number, which we can format exactly.
+	if decimals < 0 {
+		return formatElem(c, floatSelf(c, v), decimals)
+	}
+	var r big.Rat
+	switch v := v.(type) {
+	case Int:
+		r.SetInt64(int64(v))
+	case BigInt:
+		r.SetInt(v.Int)
+	case BigRat:
+		r.Set(v.Rat)
+	}
+	return r.FloatString(decimals)
+}
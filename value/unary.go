@@ -72,6 +72,11 @@ func vectorSelf(c Context, v Value) Value {
 	return NewVector([]Value{v})
 }
 
+// box encloses v in a Box.
+func box(c Context, v Value) Value {
+	return Box{v}
+}
+
 // floatSelf promotes v to type BigFloat.
 func floatSelf(c Context, v Value) Value {
 	conf := c.Config()
@@ -111,13 +116,7 @@ func init() {
 			name:        "?",
 			elementwise: true,
 			fn: [numType]unaryFn{
-				intType: func(c Context, v Value) Value {
-					i := int64(v.(Int))
-					if i <= 0 {
-						Errorf("illegal roll value %v", v)
-					}
-					return Int(c.Config().Origin()) + Int(c.Config().Random().Int63n(i))
-				},
+				intType: roll,
 				bigIntType: func(c Context, v Value) Value {
 					if v.(BigInt).Sign() <= 0 {
 						Errorf("illegal roll value %v", v)
@@ -134,6 +133,8 @@ func init() {
 				bigIntType:   self,
 				bigRatType:   self,
 				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
 				vectorType:   self,
 				matrixType:   self,
 			},
@@ -155,6 +156,10 @@ func init() {
 				bigFloatType: func(c Context, v Value) Value {
 					return unaryBigFloatOp(c, bigFloatWrap((*big.Float).Neg), v)
 				},
+				complexType: func(c Context, v Value) Value {
+					z := v.(Complex)
+					return newComplex(c.EvalUnary("-", z.real), c.EvalUnary("-", z.imag))
+				},
 			},
 		},
 
@@ -192,6 +197,9 @@ func init() {
 						Float: one.Quo(one, f.Float),
 					}.shrink()
 				},
+				complexType: func(c Context, v Value) Value {
+					return c.EvalBinary(one, "/", v)
+				},
 			},
 		},
 
@@ -218,6 +226,9 @@ func init() {
 				bigFloatType: func(c Context, v Value) Value {
 					return Int(v.(BigFloat).Sign())
 				},
+				complexType: func(c Context, v Value) Value {
+					return c.EvalBinary(v, "/", complexAbs(c, v.(Complex)))
+				},
 			},
 		},
 
@@ -286,6 +297,9 @@ func init() {
 				bigFloatType: func(c Context, v Value) Value {
 					return unaryBigFloatOp(c, bigFloatWrap((*big.Float).Abs), v)
 				},
+				complexType: func(c Context, v Value) Value {
+					return complexAbs(c, v.(Complex))
+				},
 			},
 		},
 
@@ -408,6 +422,12 @@ func init() {
 				bigFloatType: func(c Context, v Value) Value {
 					return Vector{}
 				},
+				complexType: func(c Context, v Value) Value {
+					return Vector{}
+				},
+				boxType: func(c Context, v Value) Value {
+					return Vector{}
+				},
 				vectorType: func(c Context, v Value) Value {
 					return Int(len(v.(Vector)))
 				},
@@ -425,6 +445,8 @@ func init() {
 				bigIntType:   vectorSelf,
 				bigRatType:   vectorSelf,
 				bigFloatType: vectorSelf,
+				complexType:  vectorSelf,
+				boxType:      vectorSelf,
 				vectorType:   self,
 				matrixType: func(c Context, v Value) Value {
 					return v.(Matrix).data
@@ -432,6 +454,38 @@ func init() {
 			},
 		},
 
+		{
+			name: "box",
+			fn: [numType]unaryFn{
+				intType:      self,
+				charType:     self,
+				bigIntType:   self,
+				bigRatType:   self,
+				bigFloatType: self,
+				complexType:  self,
+				boxType:      box,
+				vectorType:   box,
+				matrixType:   box,
+			},
+		},
+
+		{
+			name: "unbox",
+			fn: [numType]unaryFn{
+				intType:      self,
+				charType:     self,
+				bigIntType:   self,
+				bigRatType:   self,
+				bigFloatType: self,
+				complexType:  self,
+				boxType: func(c Context, v Value) Value {
+					return v.(Box).value
+				},
+				vectorType: self,
+				matrixType: self,
+			},
+		},
+
 		{
 			name: "up",
 			fn: [numType]unaryFn{
@@ -440,6 +494,7 @@ func init() {
 				bigIntType:   self,
 				bigRatType:   self,
 				bigFloatType: self,
+				complexType:  self,
 				vectorType: func(c Context, v Value) Value {
 					if c == nil {
 						panic("NIL IN gradeUP")
@@ -457,6 +512,7 @@ func init() {
 				bigIntType:   self,
 				bigRatType:   self,
 				bigFloatType: self,
+				complexType:  self,
 				vectorType: func(c Context, v Value) Value {
 					x := v.(Vector).grade(c)
 					for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
@@ -475,6 +531,8 @@ func init() {
 				bigIntType:   self,
 				bigRatType:   self,
 				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
 				vectorType: func(c Context, v Value) Value {
 					x := v.(Vector).Copy()
 					for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
@@ -511,6 +569,8 @@ func init() {
 				bigIntType:   self,
 				bigRatType:   self,
 				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
 				vectorType: func(c Context, v Value) Value {
 					return c.EvalUnary("rot", v)
 				},
@@ -539,6 +599,45 @@ func init() {
 			},
 		},
 
+		{
+			name: "unique",
+			fn: [numType]unaryFn{
+				intType:      self,
+				charType:     self,
+				bigIntType:   self,
+				bigRatType:   self,
+				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
+				vectorType: func(c Context, v Value) Value {
+					return unique(c, v.(Vector))
+				},
+			},
+		},
+
+		{
+			name: "transp",
+			fn: [numType]unaryFn{
+				intType:      self,
+				charType:     self,
+				bigIntType:   self,
+				bigRatType:   self,
+				bigFloatType: self,
+				complexType:  self,
+				boxType:      self,
+				vectorType:   self,
+				matrixType: func(c Context, v Value) Value {
+					// Reverse the axes.
+					m := v.(Matrix)
+					axes := make([]int, len(m.shape))
+					for i := range axes {
+						axes[i] = len(axes) - 1 - i
+					}
+					return m.transpose(axes)
+				},
+			},
+		},
+
 		{
 			name:        "cos",
 			elementwise: true,
@@ -547,6 +646,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return cos(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return cos(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return cos(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexCos(c, v.(Complex)) },
 			},
 		},
 
@@ -558,6 +658,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return logn(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return logn(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return logn(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexLog(c, v.(Complex)) },
 			},
 		},
 
@@ -569,6 +670,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return sin(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return sin(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return sin(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexSin(c, v.(Complex)) },
 			},
 		},
 
@@ -580,6 +682,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return tan(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return tan(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return tan(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexTan(c, v.(Complex)) },
 			},
 		},
 
@@ -591,6 +694,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return asin(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return asin(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return asin(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexAsin(c, v.(Complex)) },
 			},
 		},
 
@@ -602,6 +706,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return acos(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return acos(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return acos(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexAcos(c, v.(Complex)) },
 			},
 		},
 
@@ -613,6 +718,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return atan(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return atan(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return atan(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexAtan(c, v.(Complex)) },
 			},
 		},
 
@@ -624,6 +730,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return exp(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return exp(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return exp(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexExp(c, v.(Complex)) },
 			},
 		},
 
@@ -635,6 +742,134 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return sqrt(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return sqrt(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return sqrt(c, v) },
+				complexType:  func(c Context, v Value) Value { return complexSqrt(c, v.(Complex)) },
+			},
+		},
+
+		{
+			name:        "sinh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      sinh,
+				bigIntType:   sinh,
+				bigRatType:   sinh,
+				bigFloatType: sinh,
+			},
+		},
+
+		{
+			name:        "cosh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      cosh,
+				bigIntType:   cosh,
+				bigRatType:   cosh,
+				bigFloatType: cosh,
+			},
+		},
+
+		{
+			name:        "tanh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      tanh,
+				bigIntType:   tanh,
+				bigRatType:   tanh,
+				bigFloatType: tanh,
+			},
+		},
+
+		{
+			name:        "asinh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      asinh,
+				bigIntType:   asinh,
+				bigRatType:   asinh,
+				bigFloatType: asinh,
+			},
+		},
+
+		{
+			name:        "acosh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      acosh,
+				bigIntType:   acosh,
+				bigRatType:   acosh,
+				bigFloatType: acosh,
+			},
+		},
+
+		{
+			name:        "atanh",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      atanh,
+				bigIntType:   atanh,
+				bigRatType:   atanh,
+				bigFloatType: atanh,
+			},
+		},
+
+		{
+			name:        "!",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      factorial,
+				bigIntType:   factorial,
+				bigRatType:   factorial,
+				bigFloatType: factorial,
+			},
+		},
+
+		{
+			name:        "isprime",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:    isPrime,
+				bigIntType: isPrime,
+			},
+		},
+
+		{
+			name: "factors",
+			fn: [numType]unaryFn{
+				intType:    factors,
+				bigIntType: factors,
+			},
+		},
+
+		{
+			name:        "randn",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      normal,
+				bigIntType:   normal,
+				bigRatType:   normal,
+				bigFloatType: normal,
+			},
+		},
+
+		{
+			name:        "randexp",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      exponentialRand,
+				bigIntType:   exponentialRand,
+				bigRatType:   exponentialRand,
+				bigFloatType: exponentialRand,
+			},
+		},
+
+		{
+			name:        "gamma",
+			elementwise: true,
+			fn: [numType]unaryFn{
+				intType:      gamma,
+				bigIntType:   gamma,
+				bigRatType:   gamma,
+				bigFloatType: gamma,
 			},
 		},
 
@@ -661,6 +896,7 @@ func init() {
 				bigIntType:   func(c Context, v Value) Value { return text(c, v) },
 				bigRatType:   func(c Context, v Value) Value { return text(c, v) },
 				bigFloatType: func(c Context, v Value) Value { return text(c, v) },
+				complexType:  func(c Context, v Value) Value { return text(c, v) },
 				vectorType:   func(c Context, v Value) Value { return text(c, v) },
 				matrixType:   func(c Context, v Value) Value { return text(c, v) },
 			},
@@ -687,6 +923,82 @@ func init() {
 				bigIntType:   floatSelf,
 				bigRatType:   floatSelf,
 				bigFloatType: floatSelf,
+				complexType: func(c Context, v Value) Value {
+					re, im := v.(Complex).floats(c)
+					return Complex{BigFloat{re}, BigFloat{im}}
+				},
+			},
+		},
+
+		{
+			name: "det",
+			fn: [numType]unaryFn{
+				intType:      determinant,
+				bigIntType:   determinant,
+				bigRatType:   determinant,
+				bigFloatType: determinant,
+				vectorType:   determinant,
+				matrixType:   determinant,
+			},
+		},
+
+		{
+			name: "rank",
+			fn: [numType]unaryFn{
+				intType:      matrixRank,
+				bigIntType:   matrixRank,
+				bigRatType:   matrixRank,
+				bigFloatType: matrixRank,
+				vectorType:   matrixRank,
+				matrixType:   matrixRank,
+			},
+		},
+
+		{
+			name: "lu",
+			fn: [numType]unaryFn{
+				vectorType: luDecomposition,
+				matrixType: luDecomposition,
+			},
+		},
+
+		{
+			name: "qr",
+			fn: [numType]unaryFn{
+				vectorType: qrDecomposition,
+				matrixType: qrDecomposition,
+			},
+		},
+
+		{
+			name: "trace",
+			fn: [numType]unaryFn{
+				intType:      trace,
+				bigIntType:   trace,
+				bigRatType:   trace,
+				bigFloatType: trace,
+				vectorType:   trace,
+				matrixType:   trace,
+			},
+		},
+
+		{
+			name: "identity",
+			fn: [numType]unaryFn{
+				intType: identity,
+			},
+		},
+
+		{
+			name: "inv",
+			fn: [numType]unaryFn{
+				intType:      inverse,
+				bigIntType:   inverse,
+				bigRatType:   inverse,
+				bigFloatType: inverse,
+				complexType:  inverse,
+				vectorType:   inverse,
+				matrixType:   inverse,
 			},
 		},
 	}
@@ -47,6 +47,18 @@ func Errorf(format string, args ...interface{}) {
 }
 
 func Parse(conf *config.Config, s string) (Value, error) {
+	// Is it complex? In bases above 19, j is a digit.
+	if i := strings.IndexByte(s, 'j'); i >= 0 && conf.InputBase() < 20 {
+		re, err := Parse(conf, s[:i])
+		if err != nil {
+			return nil, err
+		}
+		im, err := Parse(conf, s[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		return newComplex(re, im), nil
+	}
 	// Is it a rational? If so, it's tricky.
 	if strings.ContainsRune(s, '/') {
 		elems := strings.Split(s, "/")
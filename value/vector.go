@@ -43,6 +43,17 @@ func (v Vector) makeString(conf *config.Config, spaces bool) string {
 	return b.String()
 }
 
+// elemStrings returns the printed form of each element of v.
+// An element such as a box may contain spaces, so we cannot
+// just split the result of makeString.
+func (v Vector) elemStrings(conf *config.Config) []string {
+	strs := make([]string, len(v))
+	for i, elem := range v {
+		strs[i] = elem.Sprint(conf)
+	}
+	return strs
+}
+
 // AllChars reports whether the vector contains only Chars.
 func (v Vector) AllChars() bool {
 	for _, c := range v {
@@ -152,6 +163,62 @@ func membership(c Context, u, v Vector) Value {
 	return NewVector(values).shrink()
 }
 
+// unique returns the distinct elements of v, in order of first occurrence.
+func unique(c Context, v Vector) Vector {
+	x := make(Vector, 0, len(v))
+	for _, e := range v {
+		if !hasElem(c, x, e) {
+			x = append(x, e)
+		}
+	}
+	return x
+}
+
+// union returns the distinct elements of u and v, in order of first occurrence.
+func union(c Context, u, v Vector) Vector {
+	x := make(Vector, 0, len(u)+len(v))
+	return unique(c, append(append(x, u...), v...))
+}
+
+// intersect returns the distinct elements of u that are also in v, in
+// order of first occurrence.
+func intersect(c Context, u, v Vector) Vector {
+	x := make(Vector, 0, len(u))
+	for _, e := range unique(c, u) {
+		if hasElem(c, v, e) {
+			x = append(x, e)
+		}
+	}
+	return x
+}
+
+// without returns the elements of u that are not in v, in order.
+// Unlike union and intersect, it does not discard duplicates.
+func without(c Context, u, v Vector) Vector {
+	x := make(Vector, 0, len(u))
+	for _, e := range u {
+		if !hasElem(c, v, e) {
+			x = append(x, e)
+		}
+	}
+	return x
+}
+
+// hasElem reports whether x is an element of v, as determined by ==.
+// A char is never equal to a number.
+func hasElem(c Context, v Vector, x Value) bool {
+	_, xChar := x.(Char)
+	for _, y := range v {
+		if _, yChar := y.(Char); xChar != yChar {
+			continue
+		}
+		if toBool(c.EvalBinary(x, "==", y)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (v Vector) shrink() Value {
 	if len(v) == 1 {
 		return v[0]